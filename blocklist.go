@@ -0,0 +1,40 @@
+package csp
+
+import "strings"
+
+// Blocklist permanently prevents the given sources from being added to any
+// directive. Blocklisted sources that are already present are removed, and
+// future calls to Add, Set, Merge and the other methods that add sources
+// through them silently drop the sources and report them through Lint. This
+// enforces a denylist when a policy is composed by several independent
+// modules, regardless of the order in which they run. Sources are matched
+// exactly after trimming whitespace. A directive left without sources is kept,
+// which browsers treat as 'none'. The blocklist cannot be undone and is
+// copied by Clone.
+func (p *Policy) Blocklist(sources ...string) {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	for _, source := range sources {
+		s := strings.TrimSpace(source)
+		if s == "" {
+			continue
+		}
+		if p.blocked == nil {
+			p.blocked = make(map[string]struct{}, len(sources))
+		}
+		p.blocked[s] = struct{}{}
+		for _, set := range p.directives {
+			if _, ok := set[s]; ok {
+				delete(set, s)
+				changed = true
+			}
+		}
+	}
+	if changed {
+		p.invalidateCache()
+	}
+}
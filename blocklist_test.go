@@ -0,0 +1,95 @@
+package csp
+
+import "testing"
+
+// TestPolicy_Blocklist verifies that blocklisted sources are stripped and
+// cannot be added again.
+func TestPolicy_Blocklist(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		add      func(*Policy)
+		expected string
+		wantLint string // Directive expected to have a blocklist finding.
+	}{
+		{
+			name:     "existing source is stripped",
+			add:      func(*Policy) {},
+			expected: "img-src data:; script-src 'self'",
+		},
+		{
+			name:     "Add drops source",
+			add:      func(p *Policy) { p.Add(ScriptSrc, SourceUnsafeInline, "https://a.com") },
+			expected: "img-src data:; script-src 'self' https://a.com",
+			wantLint: ScriptSrc,
+		},
+		{
+			name:     "Set drops source",
+			add:      func(p *Policy) { p.Set(StyleSrc, " "+SourceUnsafeInline) },
+			expected: "img-src data:; script-src 'self'",
+			wantLint: StyleSrc,
+		},
+		{
+			name: "Merge drops source",
+			add: func(p *Policy) {
+				other := New()
+				other.Add(StyleSrc, SourceUnsafeInline, SourceSelf)
+				p.Merge(other)
+			},
+			expected: "img-src data:; script-src 'self'; style-src 'self'",
+			wantLint: StyleSrc,
+		},
+		{
+			name:     "Add with every source blocked",
+			add:      func(p *Policy) { p.Add(StyleSrc, SourceUnsafeInline) },
+			expected: "img-src data:; script-src 'self'",
+			wantLint: StyleSrc,
+		},
+		{
+			name: "Merge with every source blocked",
+			add: func(p *Policy) {
+				other := New()
+				other.Add(StyleSrc, SourceUnsafeInline)
+				p.Merge(other)
+			},
+			expected: "img-src data:; script-src 'self'",
+			wantLint: StyleSrc,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ScriptSrc, SourceSelf, SourceUnsafeInline)
+			p.Add(ImgSrc, SchemeData)
+			p.Compile() // Prime the cache to verify invalidation
+			p.Blocklist(SourceUnsafeInline, " ")
+
+			tt.add(p)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			if tt.wantLint != "" && !hasLintMessage(p.Lint(), tt.wantLint, "blocklisted") {
+				t.Errorf("Lint() = %v, want a blocklist finding for %s", p.Lint(), tt.wantLint)
+			}
+		})
+	}
+}
+
+// TestPolicy_Blocklist_Clone verifies that clones inherit the blocklist.
+func TestPolicy_Blocklist_Clone(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Blocklist("https://evil.example.com")
+
+	cloned := p.Clone()
+	cloned.Add(ScriptSrc, "https://evil.example.com", SourceSelf)
+
+	expected := "script-src 'self'"
+	if got := cloned.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
@@ -0,0 +1,280 @@
+package csp
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Equal reports whether p and other contain exactly the same directives and
+// sources. Other settings, such as report-only marks and lint configuration,
+// are not compared.
+func (p *Policy) Equal(other *Policy) bool {
+	if other == nil {
+		return false
+	}
+	if p == other {
+		return true
+	}
+
+	a := p.normalizedDirectives(identitySource)
+	b := other.normalizedDirectives(identitySource)
+	return maps.EqualFunc(a, b, maps.Equal[map[string]struct{}])
+}
+
+// EqualIgnoringDynamic reports whether p and other contain the same directives
+// and sources when request-specific values are disregarded. Every nonce source
+// is treated as the SourceNonce placeholder, and hash sources are compared only
+// by algorithm, so two policies that differ solely in nonce values or hash
+// digests are considered equal.
+func (p *Policy) EqualIgnoringDynamic(other *Policy) bool {
+	if other == nil {
+		return false
+	}
+	if p == other {
+		return true
+	}
+
+	a := p.normalizedDirectives(normalizeDynamicSource)
+	b := other.normalizedDirectives(normalizeDynamicSource)
+	return maps.EqualFunc(a, b, maps.Equal[map[string]struct{}])
+}
+
+// Canonical returns a stable serialization of the policy for comparison and
+// storage. Directives and sources are sorted, and every nonce source, whether
+// the SourceNonce placeholder or a literal 'nonce-...' value, is written as the
+// placeholder. Unlike Compile, no nonce is ever substituted, so policies that
+// differ only in their nonce values have the same canonical form.
+func (p *Policy) Canonical() string {
+	return strings.Join(p.canonicalDirectives(), "; ")
+}
+
+// GitCanonical returns the canonical form of the policy, as for Canonical,
+// with one directive per line and a trailing newline, for storing policies in
+// version control. Adding or removing a source then changes a single line,
+// and nonce values never cause a diff. An empty policy yields an empty string.
+func (p *Policy) GitCanonical() string {
+	lines := p.canonicalDirectives()
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// canonicalDirectives returns each directive of the canonical form, with its
+// sorted sources and nonces normalized to the placeholder, in sorted order.
+func (p *Policy) canonicalDirectives() []string {
+	directives := p.normalizedDirectives(normalizeNonceSource)
+
+	result := make([]string, 0, len(directives))
+	for _, directive := range sortedKeys(directives) {
+		result = append(result, strings.Join(append([]string{directive}, sortedKeys(directives[directive])...), " "))
+	}
+	return result
+}
+
+// normalizedDirectives returns a copy of the directives with every source
+// mapped through fn. The policy is read under its lock, so the result can be
+// compared with another policy's copy without holding both locks at once.
+func (p *Policy) normalizedDirectives(fn func(string) string) map[string]map[string]struct{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make(map[string]map[string]struct{}, len(p.directives))
+	for directive, sources := range p.directives {
+		normalized := make(map[string]struct{}, len(sources))
+		for s := range sources {
+			normalized[fn(s)] = struct{}{}
+		}
+		result[directive] = normalized
+	}
+	return result
+}
+
+// normalizeNonceSource replaces nonce sources with the placeholder and leaves
+// all other sources unchanged.
+func normalizeNonceSource(source string) string {
+	if ClassifySource(source) == KindNonce {
+		return noncePlaceholder
+	}
+	return source
+}
+
+// normalizeDynamicSource replaces nonce sources with the placeholder and
+// reduces hash sources to their algorithm.
+func normalizeDynamicSource(source string) string {
+	if ClassifySource(source) == KindHash {
+		algo, _, _ := strings.Cut(source, "-")
+		return algo + "'"
+	}
+	return normalizeNonceSource(source)
+}
+
+// Diff compares the policy with other and returns the changes that turn the
+// policy into other: the sources other adds and the sources it removes, per
+// directive, sorted. A directive that is added or removed as a whole appears
+// with all of its sources, or with an empty slice if it is valueless.
+// Directives without changes are omitted from both maps. A nil other is
+// treated as an empty policy.
+func (p *Policy) Diff(other *Policy) (map[string][]string, map[string][]string) {
+	from := p.normalizedDirectives(identitySource)
+	to := map[string]map[string]struct{}{}
+	if other != nil {
+		to = other.normalizedDirectives(identitySource)
+	}
+	return directiveDifference(to, from), directiveDifference(from, to)
+}
+
+// DiffHeader returns the changes that turn other into the policy, as computed
+// by Diff, in a compact text form for transmitting incremental updates, for
+// example:
+//
+//	-img-src data:; +script-src https://new.example.com
+//
+// Each entry names a directive prefixed with "+" for sources to add or "-"
+// for sources to remove. A "-" entry without sources removes the directive as
+// a whole, and a "+" entry without sources adds a valueless directive such as
+// upgrade-insecure-requests.
+// Entries are sorted by directive, with removals first. ApplyDiffHeader
+// applies the result to a copy of other to reproduce the policy. Policies
+// without differences yield an empty string, and a nil other is treated as an
+// empty policy.
+func (p *Policy) DiffHeader(other *Policy) string {
+	to := p.normalizedDirectives(identitySource)
+	from := map[string]map[string]struct{}{}
+	if other != nil {
+		from = other.normalizedDirectives(identitySource)
+	}
+	added := directiveDifference(to, from)
+	removed := directiveDifference(from, to)
+
+	directives := maps.Clone(to)
+	maps.Copy(directives, from)
+
+	var entries []string
+	for _, directive := range sortedKeys(directives) {
+		if _, kept := to[directive]; !kept {
+			entries = append(entries, diffEntry('-', directive, nil))
+			continue
+		}
+		if sources, ok := removed[directive]; ok {
+			entries = append(entries, diffEntry('-', directive, sources))
+		}
+		if sources, ok := added[directive]; ok {
+			entries = append(entries, diffEntry('+', directive, sources))
+		}
+	}
+	return strings.Join(entries, "; ")
+}
+
+// ApplyDiffHeader applies changes produced by DiffHeader to the policy. Added
+// sources go through the same checks as Add, such as the blocklist and the
+// source limit, and the provenance of removed sources is discarded. A
+// directive left without sources is removed, unless it is valid without
+// them, as with Set. Removing sources or directives the policy does not have
+// is not an error. If s is malformed, an error is returned and the policy is
+// left unchanged.
+func (p *Policy) ApplyDiffHeader(s string) error {
+	p.checkSealed()
+
+	type change struct {
+		add       bool
+		directive string
+		sources   []string
+	}
+
+	var changes []change
+	for _, entry := range strings.Split(s, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		op, name := fields[0][0], fields[0][1:]
+		if op != '+' && op != '-' {
+			return fmt.Errorf("diff entry %q does not start with '+' or '-'", fields[0])
+		}
+		if !isValidDirectiveName(name) {
+			return fmt.Errorf("invalid directive name %q", name)
+		}
+		changes = append(changes, change{add: op == '+', directive: normalizeDirective(name), sources: fields[1:]})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	touched := make(map[string]struct{}, len(changes))
+	for _, c := range changes {
+		touched[c.directive] = struct{}{}
+		_, ok := p.directives[c.directive]
+		switch {
+		case c.add && len(c.sources) > 0:
+			p.addSourcesUnsafe(c.directive, c.sources)
+		case c.add:
+			if !ok && isValueless(c.directive) {
+				p.directives[c.directive] = make(map[string]struct{})
+			}
+		case len(c.sources) == 0:
+			p.removeUnsafe(c.directive)
+		default:
+			for _, source := range c.sources {
+				delete(p.directives[c.directive], source)
+				delete(p.provenance[c.directive], source)
+			}
+		}
+	}
+	// Directives emptied by the changes are removed as by Set, since browsers
+	// would treat them as 'none'. This is done last so that a directive whose
+	// sources are all replaced keeps its settings.
+	for directive := range touched {
+		if sources, ok := p.directives[directive]; ok && len(sources) == 0 && !isValueless(directive) {
+			p.removeUnsafe(directive)
+		}
+	}
+	p.invalidateCache()
+	return nil
+}
+
+// diffEntry formats one DiffHeader entry.
+func diffEntry(op byte, directive string, sources []string) string {
+	return strings.Join(append([]string{string(op) + directive}, sources...), " ")
+}
+
+// Preview applies fn to a clone of the policy and returns the resulting
+// changes as reported by Diff, without modifying the policy itself. This
+// shows the effect of a configuration change before it is applied.
+func (p *Policy) Preview(fn func(*Policy)) (map[string][]string, map[string][]string) {
+	cloned := p.Clone()
+	fn(cloned)
+	return p.Diff(cloned)
+}
+
+// directiveDifference returns the sources in a that are not in b, per
+// directive. Directives present only in a are included even if they have no
+// sources.
+func directiveDifference(a, b map[string]map[string]struct{}) map[string][]string {
+	result := make(map[string][]string)
+	for directive, sources := range a {
+		other, ok := b[directive]
+		var diff []string
+		for s := range sources {
+			if _, found := other[s]; !found {
+				diff = append(diff, s)
+			}
+		}
+		switch {
+		case len(diff) > 0:
+			slices.Sort(diff)
+			result[directive] = diff
+		case !ok:
+			result[directive] = []string{}
+		}
+	}
+	return result
+}
+
+// identitySource returns source unchanged, for use with normalizedDirectives.
+func identitySource(source string) string { return source }
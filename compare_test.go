@@ -0,0 +1,403 @@
+package csp
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// TestPolicy_EqualIgnoringDynamic verifies that nonce values and hash digests
+// are ignored when comparing policies, while structural differences are not.
+func TestPolicy_EqualIgnoringDynamic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		a, b  func(*Policy)
+		equal bool
+	}{
+		{
+			name:  "different nonce values",
+			a:     func(p *Policy) { p.Add(ScriptSrc, SourceSelf, Nonce("abc")) },
+			b:     func(p *Policy) { p.Add(ScriptSrc, SourceSelf, Nonce("xyz")) },
+			equal: true,
+		},
+		{
+			name:  "nonce value and placeholder",
+			a:     func(p *Policy) { p.Add(ScriptSrc, Nonce("abc")) },
+			b:     func(p *Policy) { p.Add(ScriptSrc, SourceNonce) },
+			equal: true,
+		},
+		{
+			name:  "different hash digests with same algorithm",
+			a:     func(p *Policy) { p.Add(ScriptSrc, "'sha256-eHl6'") },
+			b:     func(p *Policy) { p.Add(ScriptSrc, "'sha256-YWJj'") },
+			equal: true,
+		},
+		{
+			name:  "different hash algorithms",
+			a:     func(p *Policy) { p.Add(ScriptSrc, "'sha256-eHl6'") },
+			b:     func(p *Policy) { p.Add(ScriptSrc, "'sha384-eHl6'") },
+			equal: false,
+		},
+		{
+			name:  "different hosts",
+			a:     func(p *Policy) { p.Add(ScriptSrc, "https://a.com") },
+			b:     func(p *Policy) { p.Add(ScriptSrc, "https://b.com") },
+			equal: false,
+		},
+		{
+			name: "missing directive",
+			a: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf)
+				p.Add(UpgradeInsecureRequests)
+			},
+			b:     func(p *Policy) { p.Add(ScriptSrc, SourceSelf) },
+			equal: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a, b := New(), New()
+			tt.a(a)
+			tt.b(b)
+			if got := a.EqualIgnoringDynamic(b); got != tt.equal {
+				t.Errorf("a.EqualIgnoringDynamic(b) = %v, want %v", got, tt.equal)
+			}
+			if got := b.EqualIgnoringDynamic(a); got != tt.equal {
+				t.Errorf("b.EqualIgnoringDynamic(a) = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+
+	t.Run("nil other", func(t *testing.T) {
+		t.Parallel()
+		if New().EqualIgnoringDynamic(nil) {
+			t.Error("EqualIgnoringDynamic(nil) = true, want false")
+		}
+	})
+}
+
+// TestPolicy_Diff verifies the added and removed sources between policies.
+func TestPolicy_Diff(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceSelf, "https://old.example.com")
+	p.Add(ImgSrc, SchemeData)
+
+	other := New()
+	other.Add(DefaultSrc, SourceSelf)
+	other.Add(ScriptSrc, SourceSelf, "https://new.example.com")
+	other.Add(UpgradeInsecureRequests)
+
+	added, removed := p.Diff(other)
+
+	wantAdded := map[string][]string{
+		ScriptSrc:               {"https://new.example.com"},
+		UpgradeInsecureRequests: {},
+	}
+	wantRemoved := map[string][]string{
+		ScriptSrc: {"https://old.example.com"},
+		ImgSrc:    {SchemeData},
+	}
+	if !maps.EqualFunc(added, wantAdded, slices.Equal[[]string]) {
+		t.Errorf("added = %v, want %v", added, wantAdded)
+	}
+	if !maps.EqualFunc(removed, wantRemoved, slices.Equal[[]string]) {
+		t.Errorf("removed = %v, want %v", removed, wantRemoved)
+	}
+
+	added, removed = p.Diff(nil)
+	if len(added) != 0 || len(removed) != 3 {
+		t.Errorf("Diff(nil) = %v, %v, want no additions and 3 removals", added, removed)
+	}
+}
+
+// TestPolicy_DiffHeader verifies the textual diff format and that applying
+// it to the original policy reproduces the target policy.
+func TestPolicy_DiffHeader(t *testing.T) {
+	t.Parallel()
+
+	target := New()
+	target.Add(DefaultSrc, SourceSelf)
+	target.Add(ScriptSrc, SourceSelf, "https://new.example.com")
+	target.Add(UpgradeInsecureRequests)
+
+	base := New()
+	base.Add(DefaultSrc, SourceSelf)
+	base.Add(ScriptSrc, SourceSelf, "https://old.example.com")
+	base.Add(ImgSrc, SchemeData)
+
+	tests := []struct {
+		name     string
+		target   *Policy
+		base     *Policy
+		expected string
+	}{
+		{
+			name:     "changed directives",
+			target:   target,
+			base:     base,
+			expected: "-img-src; -script-src https://old.example.com; +script-src https://new.example.com; +upgrade-insecure-requests",
+		},
+		{
+			name:     "reverse",
+			target:   base,
+			base:     target,
+			expected: "+img-src data:; -script-src https://new.example.com; +script-src https://old.example.com; -upgrade-insecure-requests",
+		},
+		{
+			name:     "nil base",
+			target:   target,
+			base:     nil,
+			expected: "+default-src 'self'; +script-src 'self' https://new.example.com; +upgrade-insecure-requests",
+		},
+		{
+			name:     "no changes",
+			target:   target,
+			base:     target.Clone(),
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			diff := tt.target.DiffHeader(tt.base)
+			if diff != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, diff)
+			}
+
+			applied := New()
+			if tt.base != nil {
+				applied = tt.base.Clone()
+			}
+			if err := applied.ApplyDiffHeader(diff); err != nil {
+				t.Fatalf("ApplyDiffHeader(%q) unexpected error: %v", diff, err)
+			}
+			if !applied.Equal(tt.target) {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.target.Compile(), applied.Compile())
+			}
+		})
+	}
+}
+
+// TestPolicy_ApplyDiffHeader verifies that directives left without sources
+// are removed rather than kept empty, and that provenance follows the sources.
+func TestPolicy_ApplyDiffHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		diff     string
+		expected string
+	}{
+		{
+			name:     "last source removed",
+			diff:     "-img-src data:",
+			expected: "script-src 'self'; upgrade-insecure-requests",
+		},
+		{
+			name:     "every added source blocklisted",
+			diff:     "+style-src 'unsafe-inline'",
+			expected: "img-src data:; script-src 'self'; upgrade-insecure-requests",
+		},
+		{
+			name:     "sources replaced",
+			diff:     "-script-src 'self'; +script-src https://a.com",
+			expected: "img-src data:; script-src https://a.com; upgrade-insecure-requests",
+		},
+		{
+			name:     "valueless directive kept",
+			diff:     "-upgrade-insecure-requests x; +img-src",
+			expected: "img-src data:; script-src 'self'; upgrade-insecure-requests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.AddFrom("base", ScriptSrc, SourceSelf)
+			p.Add(ImgSrc, SchemeData)
+			p.Add(UpgradeInsecureRequests)
+			p.Blocklist(SourceUnsafeInline)
+
+			if err := p.ApplyDiffHeader(tt.diff); err != nil {
+				t.Fatalf("ApplyDiffHeader(%q) unexpected error: %v", tt.diff, err)
+			}
+			want, err := Parse(tt.expected)
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.expected, err)
+			}
+			if !p.Equal(want) {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, p.Compile())
+			}
+		})
+	}
+
+	t.Run("provenance", func(t *testing.T) {
+		t.Parallel()
+		p := New()
+		p.AddFrom("base", ScriptSrc, SourceSelf, "https://a.com")
+		if err := p.ApplyDiffHeader("-script-src 'self'"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		p.Add(ScriptSrc, SourceSelf)
+		expected := map[string]map[string]string{ScriptSrc: {"https://a.com": "base"}}
+		if got := p.Provenance(); !maps.EqualFunc(got, expected, maps.Equal[map[string]string]) {
+			t.Errorf("\nexpected: %v\ngot:      %v", expected, got)
+		}
+	})
+}
+
+// TestPolicy_ApplyDiffHeader_Invalid verifies that malformed diffs are
+// rejected and leave the policy unchanged.
+func TestPolicy_ApplyDiffHeader_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		diff string
+	}{
+		{"missing operator", "+img-src data:; script-src 'self'"},
+		{"missing directive", "+img-src data:; + 'self'"},
+		{"invalid directive", "+img-src data:; -script_src"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ScriptSrc, SourceSelf)
+			if err := p.ApplyDiffHeader(tt.diff); err == nil {
+				t.Errorf("ApplyDiffHeader(%q) expected an error", tt.diff)
+			}
+			if got := p.Compile(); got != "script-src 'self'" {
+				t.Errorf("\nexpected: %s\ngot:      %s", "script-src 'self'", got)
+			}
+		})
+	}
+}
+
+// TestPolicy_Preview verifies that Preview reports the changes made by a
+// function without applying them.
+func TestPolicy_Preview(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf)
+	p.Add(ImgSrc, SchemeData)
+	original := p.Compile()
+
+	added, removed := p.Preview(func(c *Policy) {
+		c.Add(ScriptSrc, "https://new.example.com")
+		c.Remove(ImgSrc)
+	})
+
+	wantAdded := map[string][]string{ScriptSrc: {"https://new.example.com"}}
+	wantRemoved := map[string][]string{ImgSrc: {SchemeData}}
+	if !maps.EqualFunc(added, wantAdded, slices.Equal[[]string]) {
+		t.Errorf("added = %v, want %v", added, wantAdded)
+	}
+	if !maps.EqualFunc(removed, wantRemoved, slices.Equal[[]string]) {
+		t.Errorf("removed = %v, want %v", removed, wantRemoved)
+	}
+	if got := p.Compile(); got != original {
+		t.Errorf("original policy changed:\nexpected: %s\ngot:      %s", original, got)
+	}
+}
+
+// TestPolicy_Canonical verifies that Canonical sorts the policy and writes
+// every nonce source as the placeholder.
+func TestPolicy_Canonical(t *testing.T) {
+	t.Parallel()
+
+	literal := New()
+	literal.Add(StyleSrc, SourceSelf)
+	literal.Add(ScriptSrc, "https://b.com", "'nonce-abc'", SourceSelf)
+	literal.Add(UpgradeInsecureRequests)
+
+	placeholder := New()
+	placeholder.Add(UpgradeInsecureRequests)
+	placeholder.Add(ScriptSrc, SourceSelf, SourceNonce, "https://b.com")
+	placeholder.Add(StyleSrc, SourceSelf)
+
+	expected := "script-src 'self' https://b.com {{nonce}}; style-src 'self'; upgrade-insecure-requests"
+	if got := literal.Canonical(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if got := placeholder.Canonical(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if got := New().Canonical(); got != "" {
+		t.Errorf("Canonical() of empty policy = %q, want empty", got)
+	}
+}
+
+// TestPolicy_GitCanonical verifies the line-oriented canonical form and that
+// it does not depend on the order in which the policy was built.
+func TestPolicy_GitCanonical(t *testing.T) {
+	t.Parallel()
+
+	a := New()
+	a.Add(StyleSrc, SourceSelf)
+	a.Add(ScriptSrc, "https://b.com", "'nonce-abc'", SourceSelf)
+	a.Add(UpgradeInsecureRequests)
+
+	b := New()
+	b.Add(UpgradeInsecureRequests)
+	b.Add(ScriptSrc, SourceNonce)
+	b.Add(ScriptSrc, SourceSelf, "https://b.com")
+	b.Add(StyleSrc, SourceSelf)
+
+	expected := "script-src 'self' https://b.com {{nonce}}\nstyle-src 'self'\nupgrade-insecure-requests\n"
+	if got := a.GitCanonical(); got != expected {
+		t.Errorf("\nexpected: %q\ngot:      %q", expected, got)
+	}
+	if got := b.GitCanonical(); got != expected {
+		t.Errorf("\nexpected: %q\ngot:      %q", expected, got)
+	}
+	if got := New().GitCanonical(); got != "" {
+		t.Errorf("GitCanonical() of empty policy = %q, want empty", got)
+	}
+}
+
+// TestPolicy_Equal verifies exact policy comparison.
+func TestPolicy_Equal(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, "'nonce-abc'")
+
+	same := New()
+	same.Add(ScriptSrc, "'nonce-abc'", SourceSelf)
+
+	otherNonce := New()
+	otherNonce.Add(ScriptSrc, SourceSelf, "'nonce-xyz'")
+
+	tests := []struct {
+		name  string
+		other *Policy
+		want  bool
+	}{
+		{"same sources", same, true},
+		{"itself", p, true},
+		{"different nonce", otherNonce, false},
+		{"empty", New(), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.Equal(tt.other); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
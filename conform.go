@@ -0,0 +1,89 @@
+package csp
+
+import "strings"
+
+// Conforms checks the policy against an allowlist of permitted sources per
+// directive, such as the sources a platform approves for tenant-supplied
+// policies, and returns a finding for every source that is not permitted. A
+// directive without an entry in allowed is checked against the entry of the
+// nearest directive in its fallback list, typically default-src; a directive
+// with no applicable entry at all is reported as a whole. Directive names in
+// allowed are normalized, and nonce sources on either side are compared as
+// the SourceNonce placeholder, so allowing SourceNonce permits any nonce.
+// Findings have CheckNonConforming and SeverityError. It returns nil if the
+// policy conforms.
+func (p *Policy) Conforms(allowed map[string][]string) []LintResult {
+	permitted := make(map[string]map[string]struct{}, len(allowed))
+	for directive, sources := range allowed {
+		key := normalizeDirective(directive)
+		set, ok := permitted[key]
+		if !ok {
+			set = make(map[string]struct{}, len(sources))
+			permitted[key] = set
+		}
+		for _, s := range sources {
+			set[normalizeNonceSource(strings.TrimSpace(s))] = struct{}{}
+		}
+	}
+
+	directives := p.normalizedDirectives(identitySource)
+
+	var results []LintResult
+	for _, directive := range sortedKeys(directives) {
+		set, ok := permittedFor(permitted, directive)
+		if !ok {
+			results = append(results, LintResult{
+				Check:     CheckNonConforming,
+				Severity:  SeverityError,
+				Directive: directive,
+				Message:   "directive is not permitted",
+			})
+			continue
+		}
+		for _, s := range sortedKeys(directives[directive]) {
+			if _, ok := set[normalizeNonceSource(s)]; ok {
+				continue
+			}
+			results = append(results, LintResult{
+				Check:     CheckNonConforming,
+				Severity:  SeverityError,
+				Directive: directive,
+				Source:    s,
+				Message:   "source is not permitted",
+			})
+		}
+	}
+	return results
+}
+
+// RestrictToDirectives returns a clone of the policy containing only the
+// given directives, such as those of a template a tenant may customize
+// without introducing new directive types. The allowed names are normalized.
+// Unlike Conforms, which reports violations, dropped directives are removed
+// silently, together with their Lint findings. The original policy is
+// unchanged.
+func (p *Policy) RestrictToDirectives(allowed ...string) *Policy {
+	keep := make(map[string]struct{}, len(allowed))
+	for _, directive := range allowed {
+		keep[normalizeDirective(directive)] = struct{}{}
+	}
+
+	cloned := p.Clone()
+	for _, directive := range sortedKeys(cloned.directives) {
+		if _, ok := keep[directive]; !ok {
+			cloned.Remove(directive)
+		}
+	}
+	return cloned
+}
+
+// permittedFor returns the permitted sources for a directive, following its
+// fallback list, and whether any entry applies.
+func permittedFor(permitted map[string]map[string]struct{}, directive string) (map[string]struct{}, bool) {
+	for _, d := range fallbackChain(directive) {
+		if set, ok := permitted[d]; ok {
+			return set, true
+		}
+	}
+	return nil, false
+}
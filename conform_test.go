@@ -0,0 +1,98 @@
+package csp
+
+import "testing"
+
+// TestPolicy_Conforms verifies that sources outside the allowlist are
+// reported, following the default-src fallback.
+func TestPolicy_Conforms(t *testing.T) {
+	t.Parallel()
+
+	allowed := map[string][]string{
+		"Default-Src": {SourceSelf},
+		ScriptSrc:     {SourceSelf, SourceNonce, "https://cdn.example.com"},
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(*Policy)
+		want    []string // Findings as "directive source".
+		wantNil bool
+	}{
+		{
+			name: "conforming",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf, "'nonce-abc'", "https://cdn.example.com")
+				p.Add(ImgSrc, SourceSelf)
+			},
+			wantNil: true,
+		},
+		{
+			name:  "disallowed host",
+			setup: func(p *Policy) { p.Add(ScriptSrc, SourceSelf, "https://evil.example.com") },
+			want:  []string{"script-src https://evil.example.com"},
+		},
+		{
+			name:  "fallback to default-src",
+			setup: func(p *Policy) { p.Add(ImgSrc, SourceSelf, "https://cdn.example.com") },
+			want:  []string{"img-src https://cdn.example.com"},
+		},
+		{
+			name:  "directive without applicable entry",
+			setup: func(p *Policy) { p.Add(FormAction, SourceSelf) },
+			want:  []string{"form-action "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			results := p.Conforms(allowed)
+			if tt.wantNil {
+				if results != nil {
+					t.Errorf("Conforms() = %v, want nil", results)
+				}
+				return
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("Conforms() = %v, want %d finding(s)", results, len(tt.want))
+			}
+			for i, r := range results {
+				if got := r.Directive + " " + r.Source; got != tt.want[i] {
+					t.Errorf("finding %d = %q, want %q", i, got, tt.want[i])
+				}
+				if r.Check != CheckNonConforming || r.Severity != SeverityError {
+					t.Errorf("finding %d has check %q and severity %v", i, r.Check, r.Severity)
+				}
+			}
+		})
+	}
+}
+
+// TestPolicy_RestrictToDirectives verifies that only the allowed directives
+// are kept and the original policy is unchanged.
+func TestPolicy_RestrictToDirectives(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceSelf, SourceNonce)
+	p.Add(ImgSrc, SchemeData)
+	p.Add(FrameAncestors, SourceNone)
+	p.Add(ReportURI, "/csp")
+	original := p.Compile()
+
+	restricted := p.RestrictToDirectives(" Script-Src ", ImgSrc, StyleSrc)
+
+	const expected = "img-src data:; script-src 'self' 'nonce-abc'"
+	if got := restricted.Compile("abc"); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if got := p.Compile(); got != original {
+		t.Errorf("original policy changed\nexpected: %s\ngot:      %s", original, got)
+	}
+	if got := p.RestrictToDirectives().Compile(); got != "" {
+		t.Errorf("\nexpected: %s\ngot:      %s", "", got)
+	}
+}
@@ -0,0 +1,58 @@
+package csp
+
+import "fmt"
+
+// consolidationThreshold is the number of fetch directives a source must
+// appear in before ConsolidationHints suggests moving it to default-src.
+const consolidationThreshold = 3
+
+// ConsolidationHints returns advisory suggestions, one per source, for host
+// and scheme sources and 'self' that appear in at least three fetch
+// directives but not in default-src, sorted by source. Such sources could be
+// listed once in default-src, shortening the header.
+//
+// The hints are conservative suggestions rather than safe rewrites: an
+// explicit directive never inherits from default-src, so the savings only
+// materialize for directives that can be removed entirely in favor of
+// default-src, and adding a source to default-src also allows it for every
+// fetch directive without its own list. Nonces, hashes and other keywords
+// are never suggested, since their meaning depends on the directive.
+func (p *Policy) ConsolidationHints() []string {
+	directives := p.normalizedDirectives(identitySource)
+
+	found := make(map[string][]string)
+	for _, directive := range sortedKeys(directives) {
+		if _, ok := fallbackParents[directive]; !ok {
+			continue
+		}
+		for s := range directives[directive] {
+			if _, ok := directives[DefaultSrc][s]; ok || !consolidatable(s) {
+				continue
+			}
+			found[s] = append(found[s], directive)
+		}
+	}
+
+	var hints []string
+	for _, s := range sortedKeys(found) {
+		if len(found[s]) < consolidationThreshold {
+			continue
+		}
+		hints = append(hints, fmt.Sprintf(
+			"%s appears in %s; if these directives can share one source list, "+
+				"moving it to default-src and removing them would shorten the policy",
+			s, joinPhrases(found[s])))
+	}
+	return hints
+}
+
+// consolidatable reports whether a source means the same in every fetch
+// directive, so that ConsolidationHints may suggest it for default-src.
+func consolidatable(s string) bool {
+	switch ClassifySource(s) {
+	case KindHost, KindScheme:
+		return true
+	default:
+		return s == SourceSelf
+	}
+}
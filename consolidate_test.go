@@ -0,0 +1,73 @@
+package csp
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPolicy_ConsolidationHints verifies which shared sources are suggested
+// for default-src.
+func TestPolicy_ConsolidationHints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		expected []string
+	}{
+		{
+			name: "host in three directives",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, "https://cdn.example.com")
+				p.Add(StyleSrc, "https://cdn.example.com")
+				p.Add(ImgSrc, "https://cdn.example.com", SchemeData)
+			},
+			expected: []string{"https://cdn.example.com appears in img-src, script-src and style-src;"},
+		},
+		{
+			name: "below threshold",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, "https://cdn.example.com")
+				p.Add(StyleSrc, "https://cdn.example.com")
+			},
+		},
+		{
+			name: "already in default-src",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.Add(ScriptSrc, SourceSelf)
+				p.Add(StyleSrc, SourceSelf)
+				p.Add(ImgSrc, SourceSelf)
+			},
+		},
+		{
+			name: "dynamic sources and non-fetch directives",
+			setup: func(p *Policy) {
+				for _, d := range []string{ScriptSrc, StyleSrc, ImgSrc} {
+					p.Add(d, SourceNonce, SourceUnsafeInline)
+				}
+				p.Add(FormAction, SourceSelf)
+				p.Add(BaseURI, SourceSelf)
+				p.Add(FrameAncestors, SourceSelf)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+
+			got := p.ConsolidationHints()
+			if len(got) != len(tt.expected) {
+				t.Fatalf("ConsolidationHints() = %q, want %d hint(s)", got, len(tt.expected))
+			}
+			for i, prefix := range tt.expected {
+				if !strings.HasPrefix(got[i], prefix) {
+					t.Errorf("\nexpected prefix: %s\ngot:             %s", prefix, got[i])
+				}
+			}
+		})
+	}
+}
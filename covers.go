@@ -0,0 +1,207 @@
+package csp
+
+import (
+	"net/url"
+	"strings"
+)
+
+// defaultPorts maps URL schemes to their default ports.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// Covers reports whether the policy allows loading the absolute URL rawURL
+// for the given directive. The directive's fallback list is followed, so a
+// script URL is checked against default-src when script-src is not set; if no
+// directive in the list is set, the URL is allowed.
+//
+// Matching follows the source list rules of Content Security Policy Level 3
+// for scheme sources, host sources and the "*" wildcard, including the secure
+// upgrades from http to https and from ws to wss. 'self' matches the origin
+// set by WithOrigin and its secure upgrades; without an origin it matches no
+// URL. Other keywords, nonces and hashes never match a URL. Relative or
+// unparsable URLs are not covered.
+func (p *Policy) Covers(directive, rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || !u.IsAbs() || u.Hostname() == "" {
+		return false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.coversUnsafe(normalizeDirective(directive), u)
+}
+
+// coversUnsafe implements Covers for a parsed URL.
+// It assumes the caller holds the mutex.
+func (p *Policy) coversUnsafe(directive string, u *url.URL) bool {
+	for _, d := range fallbackChain(directive) {
+		sources, ok := p.directives[d]
+		if !ok {
+			continue
+		}
+		for s := range sources {
+			if s == SourceSelf && p.origin != "" {
+				if selfMatchesURL(p.origin, u) {
+					return true
+				}
+				continue
+			}
+			if sourceMatchesURL(s, u) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// sourceMatchesURL reports whether a single source expression matches u.
+func sourceMatchesURL(source string, u *url.URL) bool {
+	scheme := strings.ToLower(u.Scheme)
+	switch ClassifySource(source) {
+	case KindScheme:
+		return schemeMatches(strings.ToLower(strings.TrimSuffix(source, ":")), scheme)
+	case KindHost:
+		if source == "*" {
+			_, network := defaultPorts[scheme]
+			return network
+		}
+		return hostSourceMatches(source, u)
+	default:
+		return false
+	}
+}
+
+// selfMatchesURL reports whether 'self' matches u for the canonical document
+// origin. Besides the origin itself, an http origin allows https and wss on
+// the same host, and an https origin allows wss, provided the ports are equal
+// or both are the default for their scheme.
+func selfMatchesURL(origin string, u *url.URL) bool {
+	urlOrigin, ok := canonicalOrigin(u.Scheme + "://" + u.Host)
+	if !ok {
+		return false
+	}
+	if urlOrigin == origin {
+		return true
+	}
+
+	selfScheme, selfHostPort, _ := strings.Cut(origin, "://")
+	urlScheme, urlHostPort, _ := strings.Cut(urlOrigin, "://")
+	upgrade := (selfScheme == "http" && (urlScheme == "https" || urlScheme == "wss")) ||
+		(selfScheme == "https" && urlScheme == "wss")
+	if !upgrade {
+		return false
+	}
+
+	// canonicalOrigin drops default ports, so equal host and port strings
+	// mean equal ports or default ports on both sides.
+	return selfHostPort == urlHostPort
+}
+
+// schemeMatches reports whether a source scheme allows a URL scheme,
+// including the secure upgrades permitted by the specification.
+func schemeMatches(sourceScheme, urlScheme string) bool {
+	switch {
+	case sourceScheme == urlScheme:
+		return true
+	case sourceScheme == "http":
+		return urlScheme == "https"
+	case sourceScheme == "ws":
+		return urlScheme == "wss" || urlScheme == "https" || urlScheme == "http"
+	case sourceScheme == "wss":
+		return urlScheme == "https"
+	default:
+		return false
+	}
+}
+
+// hostSourceMatches reports whether a host source matches u.
+func hostSourceMatches(source string, u *url.URL) bool {
+	urlScheme := strings.ToLower(u.Scheme)
+
+	rest := source
+	sourceScheme := ""
+	if s, after, ok := strings.Cut(source, "://"); ok {
+		sourceScheme, rest = strings.ToLower(s), after
+	}
+	if sourceScheme == "" || sourceScheme == "*" {
+		// Without a scheme, the source matches the protected resource's scheme,
+		// which is unknown here; accept the network schemes.
+		if _, network := defaultPorts[urlScheme]; !network {
+			return false
+		}
+	} else if !schemeMatches(sourceScheme, urlScheme) {
+		return false
+	}
+
+	hostPort, path := rest, ""
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		hostPort, path = rest[:i], rest[i:]
+	}
+	host, port, hasPort := strings.Cut(hostPort, ":")
+
+	return hostMatches(strings.ToLower(host), strings.ToLower(u.Hostname())) &&
+		portMatches(port, hasPort, sourceScheme, u) &&
+		pathMatches(path, u)
+}
+
+// hostMatches reports whether a source host pattern matches a URL host.
+// A leading "*." matches any subdomain, but not the domain itself.
+func hostMatches(pattern, host string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(host, suffix)
+	}
+	return pattern == host
+}
+
+// portMatches reports whether the port of a host source allows the port of u.
+// A URL without an explicit port uses the default port of its scheme. A
+// source without a port allows the default port of the source scheme, and the
+// default HTTP port also allows the default HTTPS port as a secure upgrade,
+// but only for a URL whose scheme is https or wss.
+func portMatches(sourcePort string, hasPort bool, sourceScheme string, u *url.URL) bool {
+	if hasPort && sourcePort == "*" {
+		return true
+	}
+
+	urlScheme := strings.ToLower(u.Scheme)
+	urlPort := u.Port()
+	if urlPort == "" {
+		urlPort = defaultPorts[urlScheme]
+	}
+
+	if !hasPort {
+		if sourceScheme == "" || sourceScheme == "*" {
+			sourceScheme = urlScheme
+		}
+		sourcePort = defaultPorts[sourceScheme]
+	}
+
+	if sourcePort == urlPort {
+		return true
+	}
+	return sourcePort == "80" && urlPort == "443" && (urlScheme == "https" || urlScheme == "wss")
+}
+
+// pathMatches reports whether the path of a host source allows the path of u.
+// An empty source path matches everything, a path ending in "/" is a prefix
+// match, and any other path must match exactly.
+func pathMatches(sourcePath string, u *url.URL) bool {
+	if sourcePath == "" || sourcePath == "/" {
+		return true
+	}
+	urlPath := u.EscapedPath()
+	if strings.HasSuffix(sourcePath, "/") {
+		return strings.HasPrefix(urlPath, sourcePath)
+	}
+	return urlPath == sourcePath
+}
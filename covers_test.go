@@ -0,0 +1,272 @@
+package csp
+
+import "testing"
+
+// TestPolicy_Covers verifies URL matching against source lists, including
+// directive fallback.
+func TestPolicy_Covers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		setup     func(*Policy)
+		directive string
+		url       string
+		want      bool
+	}{
+		{
+			name:      "no applicable directive",
+			setup:     func(p *Policy) { p.Add(ImgSrc, SourceNone) },
+			directive: ScriptSrc,
+			url:       "https://any.example.com/x.js",
+			want:      true,
+		},
+		{
+			name:      "exact host",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://cdn.example.com") },
+			directive: ScriptSrc,
+			url:       "https://cdn.example.com/lib.js",
+			want:      true,
+		},
+		{
+			name:      "other host",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://cdn.example.com") },
+			directive: ScriptSrc,
+			url:       "https://evil.example.com/lib.js",
+			want:      false,
+		},
+		{
+			name:      "fallback to default-src",
+			setup:     func(p *Policy) { p.Add(DefaultSrc, "https://cdn.example.com") },
+			directive: ScriptSrcElem,
+			url:       "https://cdn.example.com/lib.js",
+			want:      true,
+		},
+		{
+			name: "explicit directive shadows default-src",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, "https://cdn.example.com")
+				p.Add(ScriptSrc, SourceSelf)
+			},
+			directive: ScriptSrc,
+			url:       "https://cdn.example.com/lib.js",
+			want:      false,
+		},
+		{
+			name:      "wildcard subdomain",
+			setup:     func(p *Policy) { p.Add(ImgSrc, "*.example.com") },
+			directive: ImgSrc,
+			url:       "https://img.example.com/a.png",
+			want:      true,
+		},
+		{
+			name:      "wildcard does not match apex",
+			setup:     func(p *Policy) { p.Add(ImgSrc, "https://*.example.com") },
+			directive: ImgSrc,
+			url:       "https://example.com/a.png",
+			want:      false,
+		},
+		{
+			name:      "url without host",
+			setup:     func(p *Policy) { p.Add(ImgSrc, SchemeData) },
+			directive: ImgSrc,
+			url:       "data:image/png;base64,AAAA",
+			want:      false,
+		},
+		{
+			name:      "https scheme source",
+			setup:     func(p *Policy) { p.Add(ImgSrc, SchemeHTTPS) },
+			directive: ImgSrc,
+			url:       "https://a.example.com/x.png",
+			want:      true,
+		},
+		{
+			name:      "http source upgrades to https",
+			setup:     func(p *Policy) { p.Add(ImgSrc, "http://example.com") },
+			directive: ImgSrc,
+			url:       "https://example.com/x.png",
+			want:      true,
+		},
+		{
+			name:      "https source does not allow http",
+			setup:     func(p *Policy) { p.Add(ImgSrc, "https://example.com") },
+			directive: ImgSrc,
+			url:       "http://example.com/x.png",
+			want:      false,
+		},
+		{
+			name:      "prefix path",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://example.com/js/") },
+			directive: ScriptSrc,
+			url:       "https://example.com/js/app.js",
+			want:      true,
+		},
+		{
+			name:      "exact path mismatch",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://example.com/js/app.js") },
+			directive: ScriptSrc,
+			url:       "https://example.com/js/other.js",
+			want:      false,
+		},
+		{
+			name:      "wildcard port",
+			setup:     func(p *Policy) { p.Add(ConnectSrc, "https://example.com:*") },
+			directive: ConnectSrc,
+			url:       "https://example.com:9000/api",
+			want:      true,
+		},
+		{
+			name:      "self is not resolved",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, SourceSelf) },
+			directive: ScriptSrc,
+			url:       "https://example.com/app.js",
+			want:      false,
+		},
+		{
+			name:      "relative url",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "*") },
+			directive: ScriptSrc,
+			url:       "/app.js",
+			want:      false,
+		},
+		{
+			name:      "star matches network schemes",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "*") },
+			directive: ScriptSrc,
+			url:       "https://any.example.com/app.js",
+			want:      true,
+		},
+		{
+			name:      "explicit default port in url",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://example.com") },
+			directive: ScriptSrc,
+			url:       "https://example.com:443/x",
+			want:      true,
+		},
+		{
+			name:      "non-default port in url",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://example.com") },
+			directive: ScriptSrc,
+			url:       "https://example.com:8443/x",
+			want:      false,
+		},
+		{
+			name:      "explicit default port in source",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://example.com:443") },
+			directive: ScriptSrc,
+			url:       "https://example.com/x",
+			want:      true,
+		},
+		{
+			name:      "non-default port in source",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://example.com:8443") },
+			directive: ScriptSrc,
+			url:       "https://example.com/x",
+			want:      false,
+		},
+		{
+			name:      "matching non-default port",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://example.com:8443") },
+			directive: ScriptSrc,
+			url:       "https://example.com:8443/x",
+			want:      true,
+		},
+		{
+			name:      "default port of another scheme",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "https://example.com") },
+			directive: ScriptSrc,
+			url:       "https://example.com:80/x",
+			want:      false,
+		},
+		{
+			name:      "secure upgrade to https",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "http://example.com") },
+			directive: ScriptSrc,
+			url:       "https://example.com/x",
+			want:      true,
+		},
+		{
+			name:      "no upgrade for http on port 443",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "http://example.com") },
+			directive: ScriptSrc,
+			url:       "http://example.com:443/x",
+			want:      false,
+		},
+		{
+			name:      "no upgrade for explicit port 80 and http on port 443",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, "example.com:80") },
+			directive: ScriptSrc,
+			url:       "http://example.com:443/x",
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			if got := p.Covers(tt.directive, tt.url); got != tt.want {
+				t.Errorf("Covers(%q, %q) = %v, want %v", tt.directive, tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicy_Covers_Origin verifies that 'self' matches the origin set by
+// WithOrigin and its secure upgrades.
+func TestPolicy_Covers_Origin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		origin string
+		url    string
+		want   bool
+	}{
+		{"same origin", "https://example.com", "https://example.com/x", true},
+		{"explicit default port", "https://example.com", "https://EXAMPLE.com:443/x", true},
+		{"other host", "https://example.com", "https://cdn.example.com/x", false},
+		{"other port", "https://example.com", "https://example.com:8443/x", false},
+		{"downgrade", "https://example.com", "http://example.com/x", false},
+		{"upgrade to https", "http://example.com", "https://example.com/x", true},
+		{"upgrade to wss", "https://example.com", "wss://example.com/x", true},
+		{"upgrade with equal port", "http://example.com:8080", "https://example.com:8080/x", true},
+		{"upgrade with other port", "http://example.com:8080", "https://example.com/x", false},
+		{"no origin", "", "https://example.com/x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ConnectSrc, SourceSelf)
+			if tt.origin != "" {
+				p = p.WithOrigin(tt.origin)
+			}
+			if got := p.Covers(ConnectSrc, tt.url); got != tt.want {
+				t.Errorf("Covers(%q) with origin %q = %v, want %v", tt.url, tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_ReportEndpointSelf verifies that a report endpoint on the
+// document origin is not reported as blocked when connect-src allows 'self'.
+func TestPolicy_Lint_ReportEndpointSelf(t *testing.T) {
+	t.Parallel()
+
+	p, err := Parse("default-src 'self'; connect-src 'self'; report-uri https://example.com/csp")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	p = p.WithOrigin("https://example.com")
+
+	results := p.Lint()
+	if hasLintMessage(results, ReportURI, "not allowed by connect-src") {
+		t.Errorf("unexpected report-endpoint-blocked finding: %v", results)
+	}
+	if !hasLintMessage(results, ReportURI, "report endpoint is on the document origin") {
+		t.Errorf("missing self-report-endpoint finding: %v", results)
+	}
+}
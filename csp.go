@@ -4,11 +4,14 @@ package csp
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"maps"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 // These are the constants for all standard CSP directives.
@@ -56,6 +59,7 @@ const (
 
 	BlockAllMixedContent    = "block-all-mixed-content"
 	RequireSRIFor           = "require-sri-for"
+	RequireTrustedTypesFor  = "require-trusted-types-for"
 	TrustedTypes            = "trusted-types"
 	UpgradeInsecureRequests = "upgrade-insecure-requests"
 )
@@ -65,14 +69,20 @@ const (
 const (
 	// Keyword Sources.
 
-	SourceSelf          = "'self'"
-	SourceUnsafeInline  = "'unsafe-inline'"
-	SourceUnsafeEval    = "'unsafe-eval'"
-	SourceNone          = "'none'"
-	SourceNonce         = noncePlaceholder
-	SourceStrictDynamic = "'strict-dynamic'"
-	SourceReportSample  = "'report-sample'"
-	SourceUnsafeHashes  = "'unsafe-hashes'" // CSP3
+	SourceSelf           = "'self'"
+	SourceUnsafeInline   = "'unsafe-inline'"
+	SourceUnsafeEval     = "'unsafe-eval'"
+	SourceNone           = "'none'"
+	SourceNonce          = noncePlaceholder
+	SourceStrictDynamic  = "'strict-dynamic'"
+	SourceReportSample   = "'report-sample'"
+	SourceUnsafeHashes   = "'unsafe-hashes'"    // CSP3
+	SourceWasmUnsafeEval = "'wasm-unsafe-eval'" // CSP3
+
+	// Trusted Types keywords.
+
+	SourceAllowDuplicates = "'allow-duplicates'" // Only valid in trusted-types
+	SourceScript          = "'script'"           // Only valid in require-trusted-types-for
 
 	// Scheme Sources.
 
@@ -84,22 +94,67 @@ const (
 	SchemeMedia = "mediastream:"
 )
 
+// These are the names of the HTTP response headers that carry a policy.
+const (
+	HeaderName           = "Content-Security-Policy"
+	HeaderNameReportOnly = "Content-Security-Policy-Report-Only"
+)
+
 // noncePlaceholder is the internal text that will be replaced by the actual nonce value.
 const noncePlaceholder = "{{nonce}}"
 
 // valuelessDirectives is a set of CSP directives that are valid without any value.
+// It is guarded by valuelessMu, since RegisterValuelessDirective may extend it
+// while policies are being modified.
 var valuelessDirectives = map[string]struct{}{
 	BlockAllMixedContent:    {},
 	UpgradeInsecureRequests: {},
 	Sandbox:                 {}, // Can be used with or without values
 }
 
+// valuelessMu guards valuelessDirectives.
+var valuelessMu sync.RWMutex
+
+// RegisterValuelessDirective adds a directive to the set of directives that
+// are valid without sources, such as experimental directives not known to
+// this package. Add and Set then accept the directive without sources, and
+// Parse accepts it without a value. The registration is global: it affects
+// every policy in the process and cannot be undone. It is safe to call
+// concurrently with other functions of the package, but is typically called
+// once during initialization.
+func RegisterValuelessDirective(name string) {
+	key := normalizeDirective(name)
+	if key == "" {
+		return
+	}
+
+	valuelessMu.Lock()
+	defer valuelessMu.Unlock()
+
+	valuelessDirectives[key] = struct{}{}
+}
+
+// isValueless reports whether a normalized directive name is valid without
+// sources.
+func isValueless(key string) bool {
+	valuelessMu.RLock()
+	defer valuelessMu.RUnlock()
+
+	_, ok := valuelessDirectives[key]
+	return ok
+}
+
 // Nonce returns a correctly formatted nonce source string for a static nonce value.
 // This function is idempotent; if the provided string is already a valid nonce
 // source, it is returned as-is after trimming whitespace.
 func Nonce(nonce string) string {
-	nonceValue := strings.TrimPrefix(strings.Trim(strings.TrimSpace(nonce), "'"), "nonce-")
-	return "'nonce-" + nonceValue + "'"
+	return "'nonce-" + bareNonce(nonce) + "'"
+}
+
+// bareNonce strips whitespace, quotes and the "nonce-" prefix from a nonce,
+// returning the raw nonce value.
+func bareNonce(nonce string) string {
+	return strings.TrimPrefix(strings.Trim(strings.TrimSpace(nonce), "'"), "nonce-")
 }
 
 // ParseHash strictly validates the hash algorithm and base64 string integrity,
@@ -145,11 +200,36 @@ func Hash(algo, base64Value string) string {
 // way to define and compile CSP headers, with support for lazy compilation
 // and per-request nonce injection.
 type Policy struct {
-	mu         sync.RWMutex
-	directives map[string]map[string]struct{} // Using a map for sources ensures automatic deduplication.
-	cache      string                         // Cached policy string with placeholders.
-	isCompiled bool                           // Flag indicating if the policy has been compiled.
-	needsNonce bool                           // Flag indicating if the compiled policy has a nonce placeholder.
+	mu             sync.RWMutex
+	directives     map[string]map[string]struct{} // Using a map for sources ensures automatic deduplication.
+	cache          string                         // Cached policy string with placeholders.
+	cacheBytes     []byte                         // Byte form of cache, shared by CompileBytes callers.
+	isCompiled     bool                           // Flag indicating if the policy has been compiled.
+	needsNonce     bool                           // Flag indicating if the compiled policy has a nonce placeholder.
+	maxSources     int                            // Maximum number of sources per directive; zero means unlimited.
+	capped         map[string]int                 // Number of sources rejected per directive due to maxSources.
+	reportOnly     map[string]struct{}            // Directives that CompileSplit emits in the report-only header.
+	memo           atomic.Pointer[nonceMemo]      // Most recent nonce substitution, reused for repeated nonces.
+	stats          policyStats                    // Compilation counters reported by Stats.
+	notes          []LintResult                   // Findings recorded when input is dropped, reported by Lint.
+	disabledChecks map[LintCheck]struct{}         // Lint checks turned off by DisableLintChecks.
+	blocked        map[string]struct{}            // Sources rejected by Blocklist.
+	origin         string                         // Document origin set by WithOrigin; empty if unknown.
+	displayNames   map[string]string              // Original spelling of directive names kept by ParseWith.
+	provenance     map[string]map[string]string   // Source labels recorded by AddFrom, per directive.
+	sorted         map[string][]string            // Sorted sources per directive from the last cache build.
+	hashGroups     [][]string                     // Equivalent hash sources recorded by AddInlineScripts.
+	seal           sealState                      // Sealed flag set by Seal; empty unless built with cspdebug.
+
+	checkpoints    map[int]map[string]map[string]struct{} // Directive snapshots saved by Checkpoint.
+	lastCheckpoint int                                    // Most recently issued checkpoint id.
+}
+
+// nonceMemo records the result of injecting a nonce into a cached policy string.
+type nonceMemo struct {
+	cache  string // Cached policy string the nonce was injected into.
+	nonce  string // Nonce argument as passed to Compile.
+	result string // Policy string with the nonce injected.
 }
 
 // New creates and returns a new, empty Policy.
@@ -163,7 +243,9 @@ func New() *Policy {
 // Any modification to the policy will cause the compiled version to be regenerated
 // on the next call to Compile.
 func (p *Policy) Add(directive string, sources ...string) {
-	key := strings.ToLower(strings.TrimSpace(directive))
+	p.checkSealed()
+
+	key := normalizeDirective(directive)
 	if key == "" {
 		return
 	}
@@ -184,7 +266,7 @@ func (p *Policy) Add(directive string, sources ...string) {
 		}
 	} else {
 		// No sources provided. Only proceed if it's a known valueless directive
-		if _, isValueless := valuelessDirectives[key]; !isValueless {
+		if !isValueless(key) {
 			return
 		}
 	}
@@ -192,11 +274,12 @@ func (p *Policy) Add(directive string, sources ...string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if _, ok := p.directives[key]; !ok {
-		p.directives[key] = make(map[string]struct{})
-	}
-	for _, s := range validSources {
-		p.directives[key][s] = struct{}{}
+	if len(validSources) == 0 {
+		if _, ok := p.directives[key]; !ok {
+			p.directives[key] = make(map[string]struct{})
+		}
+	} else {
+		p.addSourcesUnsafe(key, validSources)
 	}
 	p.invalidateCache()
 }
@@ -209,7 +292,9 @@ func (p *Policy) Add(directive string, sources ...string) {
 // Any modification to the policy will cause the compiled version to be regenerated
 // on the next call to Compile.
 func (p *Policy) Set(directive string, sources ...string) {
-	key := strings.ToLower(strings.TrimSpace(directive))
+	p.checkSealed()
+
+	key := normalizeDirective(directive)
 	if key == "" {
 		return
 	}
@@ -218,17 +303,19 @@ func (p *Policy) Set(directive string, sources ...string) {
 	defer p.mu.Unlock()
 	defer p.invalidateCache()
 
+	delete(p.capped, key)
+	delete(p.provenance, key)
 	newSources := make(map[string]struct{}, len(sources))
 	for _, source := range sources {
 		s := strings.TrimSpace(source)
 		if s != "" {
-			newSources[s] = struct{}{}
+			p.addSourceUnsafe(key, newSources, s)
 		}
 	}
 
 	// If no valid sources are provided, check if the directive can be valueless
 	if len(newSources) == 0 {
-		if _, ok := valuelessDirectives[key]; !ok {
+		if !isValueless(key) {
 			// If it's not a known valueless directive, remove it
 			delete(p.directives, key)
 			return
@@ -243,33 +330,150 @@ func (p *Policy) Set(directive string, sources ...string) {
 // Any modification to the policy will cause the compiled version to be regenerated
 // on the next call to Compile.
 func (p *Policy) Remove(directive string) {
-	key := strings.ToLower(strings.TrimSpace(directive))
+	p.checkSealed()
+
+	key := normalizeDirective(directive)
 
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	if _, ok := p.directives[key]; ok {
-		delete(p.directives, key)
+		p.removeUnsafe(key)
 		p.invalidateCache()
 	}
 }
 
+// removeUnsafe deletes a directive and everything recorded about it.
+// It assumes the caller holds the mutex and invalidates the cache.
+func (p *Policy) removeUnsafe(key string) {
+	delete(p.directives, key)
+	delete(p.capped, key)
+	delete(p.reportOnly, key)
+	delete(p.displayNames, key)
+	delete(p.provenance, key)
+	p.clearNotesUnsafe(key)
+	if key == ScriptSrc {
+		p.hashGroups = nil
+	}
+}
+
 // Compile generates the CSP header string from the policy.
 // The directives are sorted alphabetically for a consistent, testable output.
 // The sources within each directive are also sorted.
 // The first call to Compile will build and cache the policy string. Subsequent
 // calls are highly optimized. If a nonce is required, it will be injected.
 func (p *Policy) Compile(nonce ...string) string {
+	cache, _, needsNonce := p.compiledCache()
+	if !needsNonce {
+		return cache
+	}
+	return p.injectNonceMemo(cache, nonce)
+}
+
+// CompileBytes is like Compile but returns the header value as a byte slice,
+// which avoids a string-to-byte conversion when writing directly to a response.
+//
+// For policies without a nonce placeholder, the returned slice is shared with
+// the policy's internal cache and with other callers; it must not be modified.
+// It remains valid after the policy changes, since a rebuild allocates a new
+// slice rather than overwriting the old one.
+func (p *Policy) CompileBytes(nonce ...string) []byte {
+	cache, cacheBytes, needsNonce := p.compiledCache()
+	if !needsNonce {
+		return cacheBytes
+	}
+	return []byte(p.injectNonceMemo(cache, nonce))
+}
+
+// CompileInto writes the compiled policy to b, exactly as returned by Compile.
+// It allows several headers to be built into one buffer without intermediate
+// copies, and uses the same cache as Compile.
+func (p *Policy) CompileInto(b *strings.Builder, nonce ...string) {
+	cache, _, needsNonce := p.compiledCache()
+	if !needsNonce {
+		b.WriteString(cache)
+		return
+	}
+	b.WriteString(p.injectNonceMemo(cache, nonce))
+}
+
+// CompileFunc is like Compile but obtains the nonce from gen, which is called
+// only if the policy contains a nonce placeholder. This avoids generating
+// nonces for policies that do not use them. An error from gen is returned
+// wrapped, together with an empty header.
+func (p *Policy) CompileFunc(gen func() (string, error)) (string, error) {
+	cache, _, needsNonce := p.compiledCache()
+	if !needsNonce {
+		return cache, nil
+	}
+	nonce, err := gen()
+	if err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return p.injectNonceMemo(cache, []string{nonce}), nil
+}
+
+// CompileWithFreshNonce compiles the policy with a newly generated nonce of
+// 16 random bytes from crypto/rand, encoded as standard base64, and returns
+// the header together with the nonce for use in templates. A nonce is only
+// generated if the policy contains a nonce placeholder; otherwise the
+// returned nonce is empty. It is safe for concurrent use, and each call
+// yields a different nonce. An error reading random bytes is returned
+// wrapped, together with an empty header and nonce.
+func (p *Policy) CompileWithFreshNonce() (string, string, error) {
+	cache, _, needsNonce := p.compiledCache()
+	if !needsNonce {
+		return cache, "", nil
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return "", "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return p.injectNonceMemo(cache, []string{nonce}), nonce, nil
+}
+
+// ErrNonceRequired is returned by CompileSafe when the policy contains a nonce
+// placeholder but no nonce was supplied.
+var ErrNonceRequired = errors.New("policy requires a nonce but none was supplied")
+
+// CompileSafe is like Compile but returns ErrNonceRequired instead of a header
+// containing the literal nonce placeholder when the policy needs a nonce and
+// none, or only whitespace, is given. Such a header has no usable nonce, so
+// every nonce-protected script or style on the page would be blocked.
+func (p *Policy) CompileSafe(nonce ...string) (string, error) {
+	cache, _, needsNonce := p.compiledCache()
+	if !needsNonce {
+		return cache, nil
+	}
+	if len(nonce) == 0 || strings.TrimSpace(nonce[0]) == "" {
+		return "", ErrNonceRequired
+	}
+	return p.injectNonceMemo(cache, nonce), nil
+}
+
+// compiledCache returns the cached policy string, its byte form, and whether
+// it contains a nonce placeholder, building the cache first if necessary.
+// It is used by the methods that return the compiled header, and counts a
+// valid cache as a hit in Stats.
+func (p *Policy) compiledCache() (string, []byte, bool) {
+	return p.loadCache(true)
+}
+
+// peekCache is like compiledCache but does not count a cache hit, for
+// methods that inspect the compiled policy without returning it.
+func (p *Policy) peekCache() (string, []byte, bool) {
+	return p.loadCache(false)
+}
+
+// loadCache implements compiledCache and peekCache.
+func (p *Policy) loadCache(countHit bool) (string, []byte, bool) {
 	p.mu.RLock()
 	if p.isCompiled {
-		cache := p.cache
-		needsNonce := p.needsNonce
-		p.mu.RUnlock()
-
-		if !needsNonce {
-			return cache
+		defer p.mu.RUnlock()
+		if countHit {
+			p.stats.cacheHits.Add(1)
 		}
-		return p.injectNonce(cache, nonce)
+		return p.cache, p.cacheBytes, p.needsNonce
 	}
 	p.mu.RUnlock()
 
@@ -279,13 +483,10 @@ func (p *Policy) Compile(nonce ...string) string {
 	// Lazy compilation: if the cache is invalid, build it
 	if !p.isCompiled {
 		p.buildCacheUnsafe()
+	} else if countHit {
+		p.stats.cacheHits.Add(1)
 	}
-
-	// If no nonce is required, return the cached policy
-	if !p.needsNonce {
-		return p.cache
-	}
-	return p.injectNonce(p.cache, nonce)
+	return p.cache, p.cacheBytes, p.needsNonce
 }
 
 // Clone returns a deep copy of the Policy.
@@ -297,14 +498,21 @@ func (p *Policy) Clone() *Policy {
 	defer p.mu.RUnlock()
 
 	cloned := &Policy{
-		cache:      p.cache,
-		isCompiled: p.isCompiled,
-		needsNonce: p.needsNonce,
-		directives: make(map[string]map[string]struct{}, len(p.directives)),
-	}
-
-	for k, v := range p.directives {
-		cloned.directives[k] = maps.Clone(v)
+		cache:          p.cache,
+		cacheBytes:     p.cacheBytes,
+		isCompiled:     p.isCompiled,
+		needsNonce:     p.needsNonce,
+		maxSources:     p.maxSources,
+		capped:         maps.Clone(p.capped),
+		reportOnly:     maps.Clone(p.reportOnly),
+		notes:          slices.Clone(p.notes),
+		disabledChecks: maps.Clone(p.disabledChecks),
+		blocked:        maps.Clone(p.blocked),
+		origin:         p.origin,
+		displayNames:   maps.Clone(p.displayNames),
+		provenance:     cloneProvenance(p.provenance),
+		hashGroups:     slices.Clone(p.hashGroups),
+		directives:     cloneDirectives(p.directives),
 	}
 
 	return cloned
@@ -312,6 +520,8 @@ func (p *Policy) Clone() *Policy {
 
 // Strict validates the current policy for common CSP syntax errors.
 // It returns an error describing the first malformed source found, or nil if valid.
+// Sources containing non-ASCII characters, such as homoglyph hosts pasted
+// from rich-text editors, are rejected with the directive and source named.
 // Use this at startup to catch configuration errors before serving traffic.
 func (p *Policy) Strict() error {
 	p.mu.RLock()
@@ -331,7 +541,43 @@ func (p *Policy) Strict() error {
 func (p *Policy) String() string { return p.Compile() }
 
 // If a nonce is required by the policy and one was provided, inject it.
+// Only placeholders that form a whole source are replaced, so that a literal
+// source merely containing the placeholder text is never corrupted.
 func (p *Policy) injectNonce(cache string, nonce []string) string {
+	if !strings.Contains(cache, namedNoncePrefix) {
+		return replaceSource(cache, SourceNonce, nonceSource(nonce))
+	}
+	return replaceNoncePlaceholders(cache, func(string) string {
+		if len(nonce) == 0 {
+			return ""
+		}
+		return nonce[0]
+	})
+}
+
+// injectNonceMemo is like injectNonce but reuses the previous result if the
+// same nonce is injected into the same cached policy string again. This avoids
+// repeated substitution when a nonce is shared across a burst of responses.
+// The memo is keyed on the cache string itself, so a result computed from a
+// stale cache is never returned after the policy changes.
+func (p *Policy) injectNonceMemo(cache string, nonce []string) string {
+	var key string
+	if len(nonce) > 0 {
+		key = nonce[0]
+	}
+	if m := p.memo.Load(); m != nil && m.nonce == key && m.cache == cache {
+		return m.result
+	}
+	result := p.injectNonce(cache, nonce)
+	p.stats.nonceSubstitutions.Add(1)
+	p.memo.Store(&nonceMemo{cache: cache, nonce: key, result: result})
+	return result
+}
+
+// nonceSource returns the nonce source that replaces the placeholder for the
+// optional nonce argument of Compile. Without a usable nonce, the placeholder
+// itself is wrapped so that the output remains a well-formed nonce source.
+func nonceSource(nonce []string) string {
 	nonceValue := SourceNonce
 	if len(nonce) > 0 {
 		trimmed := strings.TrimSpace(nonce[0])
@@ -339,81 +585,212 @@ func (p *Policy) injectNonce(cache string, nonce []string) string {
 			nonceValue = trimmed
 		}
 	}
-	return strings.ReplaceAll(cache, SourceNonce, Nonce(nonceValue))
+	return Nonce(nonceValue)
 }
 
 // buildCacheUnsafe constructs the policy string and caches it.
 // It assumes the caller holds the mutex.
 func (p *Policy) buildCacheUnsafe() {
 	defer func() { p.isCompiled = true }()
+	p.stats.rebuilds.Add(1)
 
 	if len(p.directives) == 0 {
 		p.cache = ""
+		p.cacheBytes = nil
 		p.needsNonce = false
 		return
 	}
 
-	directiveKeys := make([]string, 0, len(p.directives))
-	for k := range p.directives {
-		directiveKeys = append(directiveKeys, k)
+	directiveKeys := sortedKeys(p.directives)
+	for key := range p.sorted {
+		if _, ok := p.directives[key]; !ok {
+			delete(p.sorted, key)
+		}
 	}
-	slices.Sort(directiveKeys)
 
 	var b strings.Builder
 	b.Grow(len(directiveKeys) * 64) // Heuristic pre-allocation to minimize growth
+	hasNonce := writeDirectives(&b, p.displayNames, directiveKeys, p.sortedSourcesUnsafe)
+
+	p.cache = b.String()
+	p.cacheBytes = []byte(p.cache)
+	p.needsNonce = hasNonce
+}
 
+// sortedSourcesUnsafe returns the sources of a directive in sorted order,
+// reusing the slice from the previous cache build if the sources have not
+// changed since, so that rebuilding after a change to one directive does not
+// sort the others again. A source set has no duplicates, so the previous
+// slice is still accurate if it has the same length and all of its elements
+// are present, which is cheaper to check than sorting. The returned slice
+// must not be modified. It assumes the caller holds the write lock.
+func (p *Policy) sortedSourcesUnsafe(key string) []string {
+	set := p.directives[key]
+	if prev, ok := p.sorted[key]; ok && len(prev) == len(set) {
+		unchanged := true
+		for _, s := range prev {
+			if _, ok := set[s]; !ok {
+				unchanged = false
+				break
+			}
+		}
+		if unchanged {
+			return prev
+		}
+	}
+
+	list := sortedSources(set, slices.Sort[[]string])
+	if p.sorted == nil {
+		p.sorted = make(map[string][]string, len(p.directives))
+	}
+	p.sorted[key] = list
+	return list
+}
+
+// writeDirectivesUnsafe serializes the given directives in order, with their
+// sources ordered by sortSources, and reports whether a nonce placeholder was
+// written. It assumes the caller holds the mutex.
+func (p *Policy) writeDirectivesUnsafe(b *strings.Builder, directiveKeys []string, sortSources func([]string)) bool {
+	return writeDirectives(b, p.displayNames, directiveKeys, func(key string) []string {
+		return sortedSources(p.directives[key], sortSources)
+	})
+}
+
+// writeDirectives serializes the given directives in order, with the ordered
+// sources returned by sourcesOf, and reports whether a nonce placeholder was
+// written. Directives with an entry in names are written with that spelling
+// instead of their key.
+func writeDirectives(
+	b *strings.Builder,
+	names map[string]string,
+	directiveKeys []string,
+	sourcesOf func(key string) []string,
+) bool {
 	var hasNonce bool
 	for i, key := range directiveKeys {
 		if i > 0 {
 			b.WriteString("; ")
 		}
-		b.WriteString(key)
-
-		sourcesMap := p.directives[key]
-		if len(sourcesMap) == 0 {
-			continue
+		if name, ok := names[key]; ok {
+			b.WriteString(name)
+		} else {
+			b.WriteString(key)
 		}
 
-		b.WriteByte(' ')
-		sourceKeys := make([]string, 0, len(sourcesMap))
-		for s := range sourcesMap {
-			sourceKeys = append(sourceKeys, s)
+		for _, s := range sourcesOf(key) {
+			b.WriteByte(' ')
+			hasNonce = hasNonce || isNoncePlaceholder(s)
+			b.WriteString(s)
 		}
-		slices.Sort(sourceKeys)
+	}
+	return hasNonce
+}
 
-		for j, s := range sourceKeys {
-			if j > 0 {
-				b.WriteByte(' ')
-			}
-			hasNonce = hasNonce || s == SourceNonce
-			b.WriteString(s)
+// sortedSources returns the sources of a set as a slice ordered by
+// sortSources, or nil for an empty set.
+func sortedSources(set map[string]struct{}, sortSources func([]string)) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(set))
+	for s := range set {
+		list = append(list, s)
+	}
+	sortSources(list)
+	return list
+}
+
+// addSourceUnsafe adds s to sources, the source set of the directive key,
+// unless the per-directive source limit has been reached or s is blocklisted.
+// It assumes the caller holds the mutex.
+func (p *Policy) addSourceUnsafe(key string, sources map[string]struct{}, s string) {
+	if _, ok := sources[s]; ok {
+		return
+	}
+	if _, ok := p.blocked[s]; ok {
+		p.noteOnceUnsafe(key, s, "source is blocklisted; dropped")
+		return
+	}
+	if p.maxSources > 0 && len(sources) >= p.maxSources {
+		if p.capped == nil {
+			p.capped = make(map[string]int)
 		}
+		p.capped[key]++
+		return
 	}
+	sources[s] = struct{}{}
+}
 
-	p.cache = b.String()
-	p.needsNonce = hasNonce
+// addSourcesUnsafe adds sources to the directive key through addSourceUnsafe.
+// A missing directive is created only if at least one source is added, so
+// that sources dropped by the blocklist or the source limit never leave an
+// empty directive behind, which browsers would treat as 'none'.
+// It assumes the caller holds the mutex.
+func (p *Policy) addSourcesUnsafe(key string, sources []string) {
+	set, ok := p.directives[key]
+	if !ok {
+		set = make(map[string]struct{}, len(sources))
+	}
+	for _, s := range sources {
+		p.addSourceUnsafe(key, set, s)
+	}
+	if !ok && len(set) > 0 {
+		p.directives[key] = set
+	}
 }
 
 // invalidateCache clears the compiled policy, forcing a rebuild on the next Compile call.
 // This must be called by any method that modifies the directives.
+// In cspdebug builds, it panics if the policy has been sealed.
 func (p *Policy) invalidateCache() {
+	p.checkSealed()
 	p.isCompiled = false
 	p.cache = ""
+	p.cacheBytes = nil
 	p.needsNonce = false
+	p.memo.Store(nil)
+}
+
+// normalizeDirective returns the canonical map key for a directive name.
+func normalizeDirective(directive string) string {
+	return strings.ToLower(strings.TrimSpace(directive))
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
 }
 
 // validateSource checks a single source string for common CSP formatting errors.
 func validateSource(source string) error {
-	// Ignore keywords, nonces, hashes, and placeholders
-	if strings.HasPrefix(source, "'") || source == noncePlaceholder {
+	// Sources must be ASCII; anything else is a copy-paste error or a homoglyph,
+	// which the error escapes so that it stands out
+	for i := range len(source) {
+		if source[i] >= utf8.RuneSelf {
+			r, _ := utf8.DecodeRuneInString(source[i:])
+			return fmt.Errorf("source %+q contains non-ASCII character %+q at byte %d", source, r, i)
+		}
+	}
+
+	// Ignore keywords, nonces, hashes, and sources that are a single
+	// placeholder, which may expand to anything
+	if strings.HasPrefix(source, "'") || isTemplateSource(source) {
 		return nil
 	}
 
-	// Wildcard check: '*' must be the entire string or start with '*.'
-	if strings.Contains(source, "*") {
-		if source != "*" && !strings.HasPrefix(source, "*.") {
-			return fmt.Errorf("invalid wildcard %q (must be '*' or '*.domain')", source)
+	// Validate the rest of a source with embedded placeholders, such as
+	// "https://{{region}}.cdn.example.com", with each standing in for a label
+	if hasTemplateToken(source) {
+		substituted := replaceTokens(source, func(string, string, bool) string { return "x" })
+		if err := validateSource(substituted); err != nil {
+			return fmt.Errorf("template source %q: %w", source, err)
 		}
+		return nil
 	}
 
 	// Catch known schemes missing the trailing colon (e.g., "https")
@@ -429,7 +806,10 @@ func validateSource(source string) error {
 		}
 	}
 
-	return nil
+	if ClassifySource(source) == KindScheme {
+		return nil
+	}
+	return validateHostSource(source)
 }
 
 // isValidSchemePrefix checks if the prefix contains only valid scheme characters.
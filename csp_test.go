@@ -1,7 +1,10 @@
 package csp
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -511,15 +514,51 @@ func TestPolicy_Strict(t *testing.T) {
 			wantErr: true,
 		},
 		{
+			// Not a malformed scheme, but not a valid host source either.
 			name:    "invalid characters in scheme prefix",
 			sources: []string{"my_scheme:value"},
+			wantErr: true,
+		},
+		{
+			name:    "valid host sources",
+			sources: []string{"https://*.example.com", "*://example.com/path/", "example.com", "https://example.com:*"},
 			wantErr: false,
 		},
+		{
+			name:    "invalid scheme in host source",
+			sources: []string{"ht!tp://example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "whitespace inside host",
+			sources: []string{"exa mple.com"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid port",
+			sources: []string{"https://example.com:http"},
+			wantErr: true,
+		},
+		{
+			name:    "wildcard in middle of host",
+			sources: []string{"https://cdn.*.example.com"},
+			wantErr: true,
+		},
 		{
 			name:    "malformed custom scheme",
 			sources: []string{"customscheme:value"},
 			wantErr: true,
 		},
+		{
+			name:    "non-ASCII host",
+			sources: []string{"https://ex\u0430mple.com"}, // Cyrillic a
+			wantErr: true,
+		},
+		{
+			name:    "non-ASCII keyword",
+			sources: []string{"'s\u0435lf'"}, // Cyrillic e
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -536,6 +575,25 @@ func TestPolicy_Strict(t *testing.T) {
 	}
 }
 
+// TestPolicy_Strict_NonASCII verifies that the error for a non-ASCII source
+// names the directive, the source and the offending character.
+func TestPolicy_Strict_NonASCII(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, "https://c\u0434n.example.com") // Cyrillic de
+
+	err := p.Strict()
+	if err == nil {
+		t.Fatal("Strict() expected error, got none")
+	}
+	for _, want := range []string{`"script-src"`, `"https://c\u0434n.example.com"`, `'\u0434'`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Strict() error = %q, want it to contain %s", err, want)
+		}
+	}
+}
+
 func TestPolicy_String(t *testing.T) {
 	t.Parallel()
 	p := New()
@@ -683,6 +741,285 @@ func TestPolicy_Compile_NonceLogic(t *testing.T) {
 	}
 }
 
+// TestPolicy_CompileBytes verifies that CompileBytes produces the same output
+// as Compile, both for static policies and for policies requiring a nonce.
+func TestPolicy_CompileBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		setup func(*Policy)
+		nonce []string
+	}{
+		{
+			name:  "empty policy",
+			setup: func(p *Policy) {},
+		},
+		{
+			name: "static policy",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.Add(UpgradeInsecureRequests)
+			},
+		},
+		{
+			name: "nonce policy",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf, SourceNonce)
+			},
+			nonce: []string{"abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			want := p.Compile(tt.nonce...)
+			if got := string(p.CompileBytes(tt.nonce...)); got != want {
+				t.Errorf("CompileBytes() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("previous result survives modification", func(t *testing.T) {
+		t.Parallel()
+		p := New()
+		p.Add(DefaultSrc, SourceSelf)
+		before := p.CompileBytes()
+		p.Set(DefaultSrc, SourceNone)
+		if got := string(before); got != "default-src 'self'" {
+			t.Errorf("earlier CompileBytes result changed to %q", got)
+		}
+		if got := string(p.CompileBytes()); got != "default-src 'none'" {
+			t.Errorf("CompileBytes() = %q, want %q", got, "default-src 'none'")
+		}
+	})
+}
+
+// TestPolicy_CompileInto verifies that CompileInto appends the compiled
+// policy to an existing builder.
+func TestPolicy_CompileInto(t *testing.T) {
+	t.Parallel()
+
+	a := New()
+	a.Add(DefaultSrc, SourceSelf)
+
+	b := New()
+	b.Add(ScriptSrc, SourceNonce)
+
+	var sb strings.Builder
+	a.CompileInto(&sb, "abc")
+	sb.WriteByte('\n')
+	b.CompileInto(&sb, "abc")
+
+	expected := "default-src 'self'\nscript-src 'nonce-abc'"
+	if got := sb.String(); got != expected {
+		t.Errorf("\nexpected: %q\ngot:      %q", expected, got)
+	}
+}
+
+// TestPolicy_CompileFunc verifies that CompileFunc calls the nonce generator
+// only when the policy needs a nonce, and propagates generator errors.
+func TestPolicy_CompileFunc(t *testing.T) {
+	t.Parallel()
+
+	errGen := errors.New("entropy exhausted")
+
+	tests := []struct {
+		name      string
+		setup     func(*Policy)
+		genErr    error
+		expected  string
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "policy without nonce",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, SourceSelf) },
+			expected:  "script-src 'self'",
+			wantCalls: 0,
+		},
+		{
+			name:      "policy with nonce",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, SourceSelf, SourceNonce) },
+			expected:  "script-src 'self' 'nonce-generated'",
+			wantCalls: 1,
+		},
+		{
+			name:      "generator error",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, SourceNonce) },
+			genErr:    errGen,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+
+			calls := 0
+			got, err := p.CompileFunc(func() (string, error) {
+				calls++
+				return "generated", tt.genErr
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("generator called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, tt.genErr) {
+					t.Errorf("CompileFunc() error = %v, want %v", err, tt.genErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompileFunc() unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_CompileSafe verifies that CompileSafe refuses to emit the nonce
+// placeholder when no nonce is supplied.
+func TestPolicy_CompileSafe(t *testing.T) {
+	t.Parallel()
+
+	static := New()
+	static.Add(ScriptSrc, SourceSelf)
+
+	dynamic := New()
+	dynamic.Add(ScriptSrc, SourceSelf, SourceNonce)
+
+	tests := []struct {
+		name     string
+		p        *Policy
+		nonce    []string
+		expected string
+		wantErr  bool
+	}{
+		{"static without nonce", static, nil, "script-src 'self'", false},
+		{"dynamic with nonce", dynamic, []string{"abc"}, "script-src 'self' 'nonce-abc'", false},
+		{"dynamic without nonce", dynamic, nil, "", true},
+		{"dynamic with blank nonce", dynamic, []string{"  "}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.p.CompileSafe(tt.nonce...)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNonceRequired) {
+					t.Errorf("CompileSafe() error = %v, want %v", err, ErrNonceRequired)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompileSafe() unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_Compile_NonceMemo verifies that repeated compilation with the
+// same nonce reuses the previous result, and that the memo never outlives a
+// policy modification.
+func TestPolicy_Compile_NonceMemo(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceNonce)
+
+	first := p.Compile("same")
+	if m := p.memo.Load(); m == nil || m.result != first {
+		t.Fatal("Compile did not record the nonce memo")
+	}
+	if got := p.Compile("same"); got != first {
+		t.Errorf("Compile(same) = %q, want %q", got, first)
+	}
+	if got := p.Compile("other"); got != "script-src 'nonce-other'" {
+		t.Errorf("Compile(other) = %q, want %q", got, "script-src 'nonce-other'")
+	}
+
+	p.Add(ScriptSrc, SourceSelf)
+	if p.memo.Load() != nil {
+		t.Error("nonce memo was not cleared after modification")
+	}
+	expected := "script-src 'self' 'nonce-same'"
+	if got := p.Compile("same"); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
+
+// TestPolicy_Compile_NonceTokenBoundary verifies that only whole placeholder
+// sources are substituted and literals containing the placeholder text are
+// left intact.
+func TestPolicy_Compile_NonceTokenBoundary(t *testing.T) {
+	t.Parallel()
+
+	const crafted = "'nonce-{{nonce}}prefix'"
+	p := New()
+	p.Add(ScriptSrc, SourceNonce, crafted, "x{{nonce}}")
+	p.Add(StyleSrc, SourceNonce)
+
+	expected := "script-src " + crafted + " x{{nonce}} 'nonce-abc'; style-src 'nonce-abc'"
+	compilers := map[string]func() string{
+		"Compile":         func() string { return p.Compile("abc") },
+		"CompileNamed":    func() string { return p.CompileNamed(map[string]string{"": "abc"}) },
+		"CompileTemplate": func() string { return p.CompileTemplate(map[string]string{"nonce": "abc"}) },
+	}
+	for name, compile := range compilers {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if got := compile(); got != expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_Compile_SortedSourceReuse verifies that a cache rebuild reuses
+// the sorted sources of unchanged directives and re-sorts changed ones.
+func TestPolicy_Compile_SortedSourceReuse(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, "https://b.com", "https://a.com")
+	p.Add(ImgSrc, SchemeData)
+	p.Compile()
+	scriptSources := p.sorted[ScriptSrc]
+
+	p.Add(ImgSrc, SchemeBlob)
+	expected := "img-src blob: data:; script-src https://a.com https://b.com"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if &p.sorted[ScriptSrc][0] != &scriptSources[0] {
+		t.Error("sorted sources of an unchanged directive were rebuilt")
+	}
+
+	// Replacing a source keeps the count but must still be detected.
+	p.Set(ScriptSrc, "https://c.com", "https://a.com")
+	p.Remove(ImgSrc)
+	expected = "script-src https://a.com https://c.com"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if _, ok := p.sorted[ImgSrc]; ok {
+		t.Error("sorted sources of a removed directive were kept")
+	}
+}
+
 // TestPolicy_LazyCompilation tests the lazy compilation of the Policy object.
 // It verifies that the first call to Compile will build and cache the policy
 // string, and that subsequent calls will use the cached value until the policy
@@ -744,6 +1081,41 @@ func TestPolicy_Set_ValuelessDirective(t *testing.T) {
 	}
 }
 
+// TestRegisterValuelessDirective verifies that a registered directive can be
+// added and parsed without sources, and that registration is safe during
+// concurrent modifications.
+func TestRegisterValuelessDirective(t *testing.T) {
+	t.Parallel()
+
+	// The registration is global, so the name must not be used by other tests.
+	const directive = "x-test-valueless"
+
+	p := New()
+	p.Add(directive)
+	if got := p.Compile(); got != "" {
+		t.Fatalf("unregistered directive without sources was added: %q", got)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			New().Add(UpgradeInsecureRequests)
+		}
+	}()
+	RegisterValuelessDirective(" X-Test-Valueless ")
+	wg.Wait()
+
+	p.Add(directive)
+	if got := p.Compile(); got != directive {
+		t.Errorf("\nexpected: %s\ngot:      %s", directive, got)
+	}
+	if _, err := Parse(directive); err != nil {
+		t.Errorf("Parse(%q) unexpected error: %v", directive, err)
+	}
+}
+
 // TestPolicy_EdgeCases tests the edge cases of the Policy object.
 // It verifies that adding an empty directive does not modify the policy,
 // and that removing a non-existent directive does not invalidate the cache.
@@ -853,3 +1225,141 @@ func BenchmarkPolicy_Compile(b *testing.B) {
 		_ = p.Compile(nonce)
 	}
 }
+
+// benchBytesSink keeps benchmark results alive so the compiler cannot
+// eliminate the conversion being measured.
+var benchBytesSink []byte
+
+// newBenchmarkPolicy returns a representative static policy without a nonce.
+func newBenchmarkPolicy() *Policy {
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceSelf, "https://cdn.example.com", "https://apis.example.com")
+	p.Add(StyleSrc, SourceSelf, "https://fonts.example.com")
+	p.Add(ImgSrc, SourceSelf, SchemeData)
+	p.Add(FrameAncestors, SourceNone)
+	p.Add(UpgradeInsecureRequests)
+	return p
+}
+
+// BenchmarkPolicy_CompileBytes benchmarks CompileBytes on a static policy,
+// which returns the cached byte slice without copying.
+func BenchmarkPolicy_CompileBytes(b *testing.B) {
+	p := newBenchmarkPolicy()
+	p.CompileBytes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		benchBytesSink = p.CompileBytes()
+	}
+}
+
+// BenchmarkPolicy_CompileToBytes benchmarks converting the result of Compile
+// to a byte slice, as a baseline for BenchmarkPolicy_CompileBytes.
+func BenchmarkPolicy_CompileToBytes(b *testing.B) {
+	p := newBenchmarkPolicy()
+	p.Compile()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		benchBytesSink = []byte(p.Compile())
+	}
+}
+
+// BenchmarkPolicy_Compile_SameNonce benchmarks repeated compilation with an
+// identical nonce, which is served from the single-entry nonce memo.
+func BenchmarkPolicy_Compile_SameNonce(b *testing.B) {
+	p := newBenchmarkPolicy()
+	p.Add(ScriptSrc, SourceNonce)
+	p.Compile("same-nonce")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		_ = p.Compile("same-nonce")
+	}
+}
+
+// benchStringSink keeps benchmark results alive so the compiler cannot
+// eliminate the compilation being measured.
+var benchStringSink string
+
+// BenchmarkPolicy_Rebuild benchmarks rebuilding the cache after a change to a
+// single directive of a large policy. The sorted sources of the unchanged
+// directives are reused instead of being sorted again.
+func BenchmarkPolicy_Rebuild(b *testing.B) {
+	p := New()
+	for _, directive := range []string{ConnectSrc, FontSrc, ImgSrc, MediaSrc, ScriptSrc, StyleSrc} {
+		for i := range 50 {
+			p.Add(directive, fmt.Sprintf("https://host%02d.example.com", i))
+		}
+	}
+	p.Compile()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := range b.N {
+		if i%2 == 0 {
+			p.Add(FrameAncestors, SourceSelf)
+		} else {
+			p.Remove(FrameAncestors)
+		}
+		benchStringSink = p.Compile()
+	}
+}
+
+// BenchmarkParse benchmarks parsing a header with 5000 host sources from a
+// string, as a baseline for BenchmarkParseReader.
+func BenchmarkParse(b *testing.B) {
+	header := largeHeader(5000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if _, err := Parse(header); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParse_ReadAll benchmarks reading a header with 5000 host sources
+// from a reader into a string and parsing it, which is what ParseReader
+// replaces.
+func BenchmarkParse_ReadAll(b *testing.B) {
+	header := largeHeader(5000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		data, err := io.ReadAll(strings.NewReader(header))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := Parse(string(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseReader benchmarks streaming a header with 5000 host sources
+// through ParseReader.
+func BenchmarkParseReader(b *testing.B) {
+	header := largeHeader(5000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if _, err := ParseReader(strings.NewReader(header)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+)
+
+// These are the directive categories reported in DirectiveView, following
+// the grouping of the Content Security Policy Level 3 specification.
+const (
+	CategoryFetch      = "fetch"      // Directives controlling where resources load from.
+	CategoryDocument   = "document"   // Directives governing document properties.
+	CategoryNavigation = "navigation" // Directives governing navigation and framing.
+	CategoryReporting  = "reporting"  // Directives configuring violation reports.
+	CategoryOther      = "other"      // Directives defined elsewhere or unknown.
+)
+
+// directiveCategories maps directives outside the fetch directives to their
+// category. Fetch directives are derived from fallbackParents.
+var directiveCategories = map[string]string{
+	BaseURI:        CategoryDocument,
+	PluginTypes:    CategoryDocument,
+	Sandbox:        CategoryDocument,
+	FormAction:     CategoryNavigation,
+	FrameAncestors: CategoryNavigation,
+	NavigateTo:     CategoryNavigation,
+	ReportTo:       CategoryReporting,
+	ReportURI:      CategoryReporting,
+}
+
+// directiveCategory returns the category of a normalized directive name.
+func directiveCategory(key string) string {
+	if _, ok := fallbackParents[key]; ok || key == DefaultSrc {
+		return CategoryFetch
+	}
+	if category, ok := directiveCategories[key]; ok {
+		return category
+	}
+	return CategoryOther
+}
+
+// DirectiveView describes one directive of a policy for display, such as in
+// a developer tools panel.
+type DirectiveView struct {
+	Name     string   // Directive name as written by Compile.
+	Category string   // One of the Category constants.
+	Sources  []string // Sources in compiled order, with nonces substituted.
+}
+
+// DebugView returns a structured view of the policy with one entry per
+// directive, in the order of Compile. Sources are sorted as in Compile and
+// nonce placeholders are substituted with the optional nonce in the same way.
+// The returned slice is a copy and can be freely modified.
+func (p *Policy) DebugView(nonce ...string) []DirectiveView {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	replacement := nonceSource(nonce)
+	views := make([]DirectiveView, 0, len(p.directives))
+	for _, key := range sortedKeys(p.directives) {
+		name := key
+		if display, ok := p.displayNames[key]; ok {
+			name = display
+		}
+		sources := sortedSources(p.directives[key], slices.Sort[[]string])
+		for i, s := range sources {
+			if isNoncePlaceholder(s) {
+				sources[i] = replacement
+			}
+		}
+		views = append(views, DirectiveView{Name: name, Category: directiveCategory(key), Sources: sources})
+	}
+	return views
+}
+
+// PrettyString returns the policy for human-readable logs, with each
+// directive on its own line, indented by two spaces and followed by its
+// sources. Directives and sources are in the order of Compile and nonce
+// placeholders are substituted with the optional nonce, as for DebugView.
+// There is no trailing newline; an empty policy yields an empty string. Use
+// GitCanonical for a form suited to storing and diffing policies.
+func (p *Policy) PrettyString(nonce ...string) string {
+	var b strings.Builder
+	for i, view := range p.DebugView(nonce...) {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("  ")
+		b.WriteString(view.Name)
+		for _, s := range view.Sources {
+			b.WriteByte(' ')
+			b.WriteString(s)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestPolicy_DebugView verifies the names, categories and substituted sources
+// of the debug view.
+func TestPolicy_DebugView(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, SourceNonce)
+	p.Add(FrameAncestors, SourceNone)
+	p.Add(ReportURI, "/csp")
+	p.Add(BaseURI, SourceSelf)
+	p.Add(UpgradeInsecureRequests)
+
+	expected := []DirectiveView{
+		{Name: BaseURI, Category: CategoryDocument, Sources: []string{SourceSelf}},
+		{Name: FrameAncestors, Category: CategoryNavigation, Sources: []string{SourceNone}},
+		{Name: ReportURI, Category: CategoryReporting, Sources: []string{"/csp"}},
+		{Name: ScriptSrc, Category: CategoryFetch, Sources: []string{SourceSelf, "'nonce-abc'"}},
+		{Name: UpgradeInsecureRequests, Category: CategoryOther, Sources: nil},
+	}
+
+	got := p.DebugView("abc")
+	if !slices.EqualFunc(got, expected, func(a, b DirectiveView) bool {
+		return a.Name == b.Name && a.Category == b.Category && slices.Equal(a.Sources, b.Sources)
+	}) {
+		t.Errorf("\nexpected: %v\ngot:      %v", expected, got)
+	}
+
+	got[3].Sources[0] = "changed"
+	if again := p.DebugView("abc"); again[3].Sources[0] != SourceSelf {
+		t.Error("DebugView() returned sources shared with the policy")
+	}
+}
+
+// TestPolicy_PrettyString verifies the one-directive-per-line log format.
+func TestPolicy_PrettyString(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceNonce, SourceSelf)
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(UpgradeInsecureRequests)
+
+	expected := "  default-src 'self'\n  script-src 'self' 'nonce-abc'\n  upgrade-insecure-requests"
+	got := p.PrettyString("abc")
+	if got != expected {
+		t.Errorf("\nexpected: %q\ngot:      %q", expected, got)
+	}
+	if lines := strings.Split(got, "\n"); len(lines) != 3 {
+		t.Errorf("PrettyString() has %d lines, want 3", len(lines))
+	}
+	if got := New().PrettyString(); got != "" {
+		t.Errorf("PrettyString() of empty policy = %q, want empty", got)
+	}
+}
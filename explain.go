@@ -0,0 +1,134 @@
+package csp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// explainPrefixes maps source-list directives to the opening of the sentence
+// that Explain completes with the allowed sources.
+var explainPrefixes = map[string]string{
+	DefaultSrc:     "All content defaults to",
+	ScriptSrc:      "Scripts may load from",
+	ScriptSrcElem:  "Script elements may load from",
+	ScriptSrcAttr:  "Inline event handlers may come from",
+	StyleSrc:       "Styles may load from",
+	StyleSrcElem:   "Style elements may load from",
+	StyleSrcAttr:   "Inline style attributes may come from",
+	ImgSrc:         "Images may load from",
+	FontSrc:        "Fonts may load from",
+	ConnectSrc:     "Connections (fetch, XHR, WebSocket) may go to",
+	MediaSrc:       "Audio and video may load from",
+	ObjectSrc:      "Plugins (object and embed) may load from",
+	FrameSrc:       "Frames may load from",
+	ChildSrc:       "Frames and workers may load from",
+	WorkerSrc:      "Workers may load from",
+	ManifestSrc:    "Web app manifests may load from",
+	PrefetchSrc:    "Prefetched resources may load from",
+	BaseURI:        "The <base> element may point to",
+	FormAction:     "Forms may submit to",
+	FrameAncestors: "The page may be embedded by",
+	NavigateTo:     "The page may navigate to",
+}
+
+// explainKeywords maps keyword sources to prose.
+var explainKeywords = map[string]string{
+	SourceSelf:          "the same origin",
+	SourceUnsafeInline:  "inline code",
+	SourceUnsafeEval:    "dynamically evaluated code (eval)",
+	SourceUnsafeHashes:  "event handlers matching a hash",
+	SourceStrictDynamic: "scripts loaded by already trusted scripts",
+}
+
+// Explain describes the policy in plain English, with one sentence per
+// directive in alphabetical order of the directives. For example, default-src
+// 'self' is explained as "All content defaults to the same origin only." It
+// is intended for onboarding and audits, not as a precise specification;
+// directives without a dedicated description are listed with their sources.
+func (p *Policy) Explain() []string {
+	directives := p.normalizedDirectives(identitySource)
+
+	sentences := make([]string, 0, len(directives))
+	for _, directive := range sortedKeys(directives) {
+		sentences = append(sentences, explainDirective(directive, sortedKeys(directives[directive])))
+	}
+	return sentences
+}
+
+// explainDirective returns the sentence describing a directive with the given
+// sorted sources.
+func explainDirective(directive string, sources []string) string {
+	switch directive {
+	case UpgradeInsecureRequests:
+		return "Insecure HTTP requests are upgraded to HTTPS."
+	case BlockAllMixedContent:
+		return "Mixed content is blocked."
+	case Sandbox:
+		if len(sources) == 0 {
+			return "The page is sandboxed with all restrictions."
+		}
+		return "The page is sandboxed, except for: " + strings.Join(sources, ", ") + "."
+	case ReportURI, ReportTo:
+		return "Violation reports are sent to " + strings.Join(sources, ", ") + "."
+	}
+
+	prefix, ok := explainPrefixes[directive]
+	if !ok {
+		return fmt.Sprintf("%s is set to %q.", directive, strings.Join(sources, " "))
+	}
+	if len(sources) == 0 || (len(sources) == 1 && sources[0] == SourceNone) {
+		return prefix + " nowhere; everything is blocked."
+	}
+
+	phrases := explainSources(sources)
+	if len(phrases) == 1 {
+		return prefix + " " + phrases[0] + " only."
+	}
+	return prefix + " " + joinPhrases(phrases) + "."
+}
+
+// joinPhrases joins phrases as an English list, such as "a, b and c".
+func joinPhrases(phrases []string) string {
+	if len(phrases) < 2 {
+		return strings.Join(phrases, "")
+	}
+	return strings.Join(phrases[:len(phrases)-1], ", ") + " and " + phrases[len(phrases)-1]
+}
+
+// explainSources maps sources to prose, listing keywords first and combining
+// nonces and hashes into a single phrase each.
+func explainSources(sources []string) []string {
+	var keywords, others []string
+	var nonce bool
+	var hashes int
+	for _, s := range sources {
+		switch kind := ClassifySource(s); {
+		case kind == KindNonce:
+			nonce = true
+		case kind == KindHash:
+			hashes++
+		case s == SourceReportSample || s == SourceNone:
+			// Not a location; 'none' is ignored alongside other sources.
+		case explainKeywords[s] != "":
+			keywords = append(keywords, explainKeywords[s])
+		case s == "*":
+			others = append(others, "any URL except data:, blob: and filesystem:")
+		case kind == KindScheme:
+			others = append(others, "any "+s+" URL")
+		default:
+			others = append(others, s)
+		}
+	}
+
+	phrases := keywords
+	if nonce {
+		phrases = append(phrases, "any source with a valid nonce")
+	}
+	switch {
+	case hashes == 1:
+		phrases = append(phrases, "content matching a hash")
+	case hashes > 1:
+		phrases = append(phrases, fmt.Sprintf("content matching one of %d hashes", hashes))
+	}
+	return append(phrases, others...)
+}
@@ -0,0 +1,72 @@
+package csp
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestPolicy_Explain verifies the sentences produced for common directives.
+func TestPolicy_Explain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		expected []string
+	}{
+		{
+			name:     "default-src self",
+			setup:    func(p *Policy) { p.Add(DefaultSrc, SourceSelf) },
+			expected: []string{"All content defaults to the same origin only."},
+		},
+		{
+			name:     "script-src with nonce",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, SourceNonce, SourceSelf) },
+			expected: []string{"Scripts may load from the same origin and any source with a valid nonce."},
+		},
+		{
+			name: "mixed sources",
+			setup: func(p *Policy) {
+				p.Add(ImgSrc, "https://cdn.example.com", SchemeData, SourceSelf, "'sha256-eHl6'", "'sha384-eHl6'")
+			},
+			expected: []string{
+				"Images may load from the same origin, content matching one of 2 hashes, " +
+					"any data: URL and https://cdn.example.com.",
+			},
+		},
+		{
+			name: "none and valueless",
+			setup: func(p *Policy) {
+				p.Add(ObjectSrc, SourceNone)
+				p.Add(UpgradeInsecureRequests)
+				p.Add(Sandbox)
+			},
+			expected: []string{
+				"Plugins (object and embed) may load from nowhere; everything is blocked.",
+				"The page is sandboxed with all restrictions.",
+				"Insecure HTTP requests are upgraded to HTTPS.",
+			},
+		},
+		{
+			name:     "unknown directive",
+			setup:    func(p *Policy) { p.Add(TrustedTypes, "default", "dompurify") },
+			expected: []string{`trusted-types is set to "default dompurify".`},
+		},
+		{
+			name:     "empty policy",
+			setup:    func(*Policy) {},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			if got := p.Explain(); !slices.Equal(got, tt.expected) {
+				t.Errorf("\nexpected: %q\ngot:      %q", tt.expected, got)
+			}
+		})
+	}
+}
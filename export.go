@@ -0,0 +1,94 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+)
+
+// AsMap returns the policy as a map from directive name to its sorted sources,
+// with the nonce placeholder substituted as in Compile. Valueless directives
+// map to empty slices. The returned map is a copy and can be freely modified.
+func (p *Policy) AsMap(nonce ...string) map[string][]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	replacement := nonceSource(nonce)
+	result := make(map[string][]string, len(p.directives))
+	for directive, sources := range p.directives {
+		list := make([]string, 0, len(sources))
+		for s := range sources {
+			if isNoncePlaceholder(s) {
+				s = replacement
+			}
+			list = append(list, s)
+		}
+		slices.Sort(list)
+		result[directive] = list
+	}
+	return result
+}
+
+// NginxSnippet returns an nginx add_header directive that sends the policy,
+// for example:
+//
+//	add_header Content-Security-Policy "default-src 'self'" always;
+//
+// If reportOnly is true, the Content-Security-Policy-Report-Only header is
+// used instead. Double quotes and backslashes in the policy are escaped for
+// nginx's double-quoted string syntax; single quotes need no escaping there.
+// The policy is compiled without a nonce, so a nonce placeholder must be
+// substituted by the server, e.g. with sub_filter.
+func (p *Policy) NginxSnippet(reportOnly bool) string {
+	return "add_header " + headerName(reportOnly) + " " + quoteConfig(p.Compile(), "") + " always;"
+}
+
+// CaddySnippet returns a Caddyfile header directive that sends the policy,
+// for example:
+//
+//	header Content-Security-Policy "default-src 'self'"
+//
+// If reportOnly is true, the Content-Security-Policy-Report-Only header is
+// used instead. Double quotes, backslashes and braces are escaped, the latter
+// so that Caddy does not interpret them as placeholders. The policy is
+// compiled without a nonce, as for NginxSnippet.
+func (p *Policy) CaddySnippet(reportOnly bool) string {
+	return "header " + headerName(reportOnly) + " " + quoteConfig(p.Compile(), "{}")
+}
+
+// CurlHeader returns a curl -H argument that sends the policy compiled with
+// the given nonce, for reproducing a response in bug reports, for example:
+//
+//	-H "Content-Security-Policy: default-src 'self'"
+//
+// The header is wrapped in double quotes for POSIX shells, so single-quoted
+// keyword sources are kept as they are, while double quotes, backslashes,
+// dollar signs and backticks are escaped with a backslash. If nonce is empty,
+// the nonce placeholder is left in place.
+func (p *Policy) CurlHeader(nonce string) string {
+	return "-H " + quoteConfig(HeaderName+": "+p.Compile(nonce), "$`")
+}
+
+// headerName returns the enforcing or report-only header name.
+func headerName(reportOnly bool) string {
+	if reportOnly {
+		return HeaderNameReportOnly
+	}
+	return HeaderName
+}
+
+// quoteConfig wraps s in double quotes, escaping double quotes, backslashes
+// and any of the extra characters with a backslash.
+func quoteConfig(s, extra string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i := range len(s) {
+		c := s[i]
+		if c == '"' || c == '\\' || strings.IndexByte(extra, c) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
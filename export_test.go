@@ -0,0 +1,111 @@
+package csp
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// TestPolicy_AsMap verifies that AsMap returns sorted sources per directive
+// with the nonce placeholder substituted.
+func TestPolicy_AsMap(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, SourceNonce, "https://b.com")
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(UpgradeInsecureRequests)
+
+	tests := []struct {
+		name  string
+		nonce []string
+		want  map[string][]string
+	}{
+		{
+			name:  "with nonce",
+			nonce: []string{"abc"},
+			want: map[string][]string{
+				DefaultSrc:              {SourceSelf},
+				ScriptSrc:               {"'nonce-abc'", SourceSelf, "https://b.com"},
+				UpgradeInsecureRequests: {},
+			},
+		},
+		{
+			name: "without nonce",
+			want: map[string][]string{
+				DefaultSrc:              {SourceSelf},
+				ScriptSrc:               {"'nonce-{{nonce}}'", SourceSelf, "https://b.com"},
+				UpgradeInsecureRequests: {},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := p.AsMap(tt.nonce...)
+			if !maps.EqualFunc(got, tt.want, slices.Equal[[]string]) {
+				t.Errorf("AsMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicy_ConfigSnippets verifies the nginx, Caddy and curl snippets, including
+// escaping of characters that are special in each configuration syntax.
+func TestPolicy_ConfigSnippets(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceNonce, `https://a.com/"x"\`)
+
+	shell := New()
+	shell.Add(ReportURI, "/r?$x=`id`")
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{
+			name:     "nginx",
+			got:      p.NginxSnippet(false),
+			expected: `add_header Content-Security-Policy "default-src 'self'; script-src https://a.com/\"x\"\\ 'nonce-{{nonce}}'" always;`,
+		},
+		{
+			name:     "nginx report-only",
+			got:      New().NginxSnippet(true),
+			expected: `add_header Content-Security-Policy-Report-Only "" always;`,
+		},
+		{
+			name:     "caddy",
+			got:      p.CaddySnippet(false),
+			expected: `header Content-Security-Policy "default-src 'self'; script-src https://a.com/\"x\"\\ 'nonce-\{\{nonce\}\}'"`,
+		},
+		{
+			name:     "caddy report-only",
+			got:      p.CaddySnippet(true)[:len("header Content-Security-Policy-Report-Only ")],
+			expected: "header Content-Security-Policy-Report-Only ",
+		},
+		{
+			name:     "curl",
+			got:      p.CurlHeader("abc"),
+			expected: `-H "Content-Security-Policy: default-src 'self'; script-src https://a.com/\"x\"\\ 'nonce-abc'"`,
+		},
+		{
+			name:     "curl shell expansion",
+			got:      shell.CurlHeader(""),
+			expected: "-H \"Content-Security-Policy: report-uri /r?\\$x=\\`id\\`\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if tt.got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, tt.got)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package csp
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// fallbackParents maps each fetch directive to the directive a browser
+// consults when it is absent. Following the chain from a directive yields its
+// fallback list as defined by the "directive fallback list" algorithm of
+// Content Security Policy Level 3, section 6.8.3:
+// https://www.w3.org/TR/CSP3/#directive-fallback-list
+var fallbackParents = map[string]string{
+	ChildSrc:      DefaultSrc,
+	ConnectSrc:    DefaultSrc,
+	FontSrc:       DefaultSrc,
+	FrameSrc:      ChildSrc,
+	ImgSrc:        DefaultSrc,
+	ManifestSrc:   DefaultSrc,
+	MediaSrc:      DefaultSrc,
+	ObjectSrc:     DefaultSrc,
+	PrefetchSrc:   DefaultSrc,
+	ScriptSrc:     DefaultSrc,
+	ScriptSrcAttr: ScriptSrc,
+	ScriptSrcElem: ScriptSrc,
+	StyleSrc:      DefaultSrc,
+	StyleSrcAttr:  StyleSrc,
+	StyleSrcElem:  StyleSrc,
+	WorkerSrc:     ChildSrc,
+}
+
+// workerFallback is the fallback list of worker-src. It cannot be derived from
+// fallbackParents because worker-src consults script-src after child-src,
+// whereas child-src itself falls back directly to default-src.
+var workerFallback = []string{WorkerSrc, ChildSrc, ScriptSrc, DefaultSrc}
+
+// FallbackChildren returns the directives that fall back to the given
+// directive, directly or transitively, sorted alphabetically. For example,
+// every fetch directive falls back to default-src, and script-src-elem and
+// script-src-attr fall back to script-src. It returns nil for directives that
+// nothing falls back to. The relationships follow the directive fallback list
+// of Content Security Policy Level 3.
+func FallbackChildren(directive string) []string {
+	key := normalizeDirective(directive)
+
+	var children []string
+	for child := range fallbackParents {
+		if child != key && slices.Contains(fallbackChain(child), key) {
+			children = append(children, child)
+		}
+	}
+	slices.Sort(children)
+	return children
+}
+
+// fallbackChain returns the ordered list of directives a browser consults for
+// the given directive, starting with the directive itself. Directives without
+// a fallback yield a single-element chain.
+func fallbackChain(directive string) []string {
+	if directive == WorkerSrc {
+		return slices.Clone(workerFallback)
+	}
+	chain := []string{directive}
+	for parent, ok := fallbackParents[directive]; ok; parent, ok = fallbackParents[parent] {
+		chain = append(chain, parent)
+	}
+	return chain
+}
+
+// Expand returns a clone of the policy in which every fetch directive that
+// would fall back to another directive is written out explicitly with the
+// sources of the directive it falls back to. For example, a policy with only
+// default-src 'self' expands to one where script-src, img-src and every other
+// fetch directive are set to 'self'. Directives that are already set, and
+// directives without a set directive in their fallback list, are unchanged.
+// The expanded policy enforces the same restrictions as the original.
+func (p *Policy) Expand() *Policy {
+	cloned := p.Clone()
+
+	added := make(map[string]map[string]struct{})
+	for directive := range fallbackParents {
+		if _, ok := cloned.directives[directive]; ok {
+			continue
+		}
+		for _, d := range fallbackChain(directive)[1:] {
+			if sources, ok := cloned.directives[d]; ok {
+				added[directive] = maps.Clone(sources)
+				break
+			}
+		}
+	}
+	if len(added) > 0 {
+		maps.Copy(cloned.directives, added)
+		cloned.invalidateCache()
+	}
+	return cloned
+}
+
+// RedundantDirectives returns the fetch directives that can be removed
+// without changing what the policy allows, sorted alphabetically: those whose
+// sources are identical to default-src and that fall back to it, either
+// directly or through directives that are likewise identical. For example,
+// font-src 'self' is redundant next to default-src 'self'. The policy is not
+// modified, so that the result can be reviewed before removing the
+// directives; removing all of them at once is safe. It returns nil if
+// default-src is not set.
+func (p *Policy) RedundantDirectives() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	defaults, ok := p.directives[DefaultSrc]
+	if !ok {
+		return nil
+	}
+
+	var redundant []string
+	for _, directive := range sortedKeys(p.directives) {
+		if _, ok := fallbackParents[directive]; !ok {
+			continue
+		}
+		identical := true
+		for _, d := range fallbackChain(directive) {
+			if sources, ok := p.directives[d]; ok && !maps.Equal(sources, defaults) {
+				identical = false
+				break
+			}
+		}
+		if identical {
+			redundant = append(redundant, directive)
+		}
+	}
+	return redundant
+}
+
+// resourceDirectives maps resource types accepted by RelevantFor to the most
+// specific directive governing them.
+var resourceDirectives = map[string]string{
+	"connect":  ConnectSrc,
+	"font":     FontSrc,
+	"frame":    FrameSrc,
+	"image":    ImgSrc,
+	"manifest": ManifestSrc,
+	"media":    MediaSrc,
+	"object":   ObjectSrc,
+	"script":   ScriptSrcElem,
+	"style":    StyleSrcElem,
+	"worker":   WorkerSrc,
+}
+
+// RelevantFor returns a clone of the policy containing only the directives a
+// browser consults when loading a resource of the given type: the most
+// specific directive and the directives it falls back to. For example,
+// "image" keeps img-src and default-src. Supported types are "connect",
+// "font", "frame", "image", "manifest", "media", "object", "script", "style"
+// and "worker"; for any other type the returned policy is empty.
+func (p *Policy) RelevantFor(resourceType string) *Policy {
+	cloned := p.Clone()
+
+	var chain []string
+	if directive, ok := resourceDirectives[strings.ToLower(strings.TrimSpace(resourceType))]; ok {
+		chain = fallbackChain(directive)
+	}
+
+	changed := false
+	for directive := range cloned.directives {
+		if !slices.Contains(chain, directive) {
+			delete(cloned.directives, directive)
+			changed = true
+		}
+	}
+	if changed {
+		cloned.invalidateCache()
+	}
+	return cloned
+}
@@ -0,0 +1,220 @@
+package csp
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestFallbackChildren verifies the directives reported as falling back to a
+// given directive.
+func TestFallbackChildren(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		directive   string
+		contains    []string
+		notContains []string
+	}{
+		{
+			directive:   DefaultSrc,
+			contains:    []string{ScriptSrc, ImgSrc, StyleSrc, ScriptSrcElem, WorkerSrc, FrameSrc},
+			notContains: []string{DefaultSrc, BaseURI, FrameAncestors},
+		},
+		{
+			directive:   ScriptSrc,
+			contains:    []string{ScriptSrcElem, ScriptSrcAttr, WorkerSrc},
+			notContains: []string{ScriptSrc, StyleSrc, FrameSrc},
+		},
+		{
+			directive:   ChildSrc,
+			contains:    []string{FrameSrc, WorkerSrc},
+			notContains: []string{ScriptSrc},
+		},
+		{
+			directive:   "Script-Src",
+			contains:    []string{ScriptSrcElem},
+			notContains: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.directive, func(t *testing.T) {
+			t.Parallel()
+			got := FallbackChildren(tt.directive)
+			if !slices.IsSorted(got) {
+				t.Errorf("FallbackChildren(%q) = %v, not sorted", tt.directive, got)
+			}
+			for _, d := range tt.contains {
+				if !slices.Contains(got, d) {
+					t.Errorf("FallbackChildren(%q) = %v, missing %q", tt.directive, got, d)
+				}
+			}
+			for _, d := range tt.notContains {
+				if slices.Contains(got, d) {
+					t.Errorf("FallbackChildren(%q) = %v, unexpectedly contains %q", tt.directive, got, d)
+				}
+			}
+		})
+	}
+
+	t.Run("leaf directive", func(t *testing.T) {
+		t.Parallel()
+		if got := FallbackChildren(ImgSrc); got != nil {
+			t.Errorf("FallbackChildren(%q) = %v, want nil", ImgSrc, got)
+		}
+	})
+}
+
+// TestPolicy_Expand verifies that Expand writes out fallbacks explicitly
+// without changing explicitly set directives or the original policy.
+func TestPolicy_Expand(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, "https://cdn.example.com")
+	p.Add(UpgradeInsecureRequests)
+	original := p.Compile()
+
+	expanded := p.Expand().AsMap()
+
+	tests := []struct {
+		directive string
+		want      []string
+	}{
+		{DefaultSrc, []string{SourceSelf}},
+		{ImgSrc, []string{SourceSelf}},
+		{StyleSrcElem, []string{SourceSelf}},
+		{FrameSrc, []string{SourceSelf}},
+		{ScriptSrc, []string{"https://cdn.example.com"}},
+		{ScriptSrcElem, []string{"https://cdn.example.com"}},
+		{WorkerSrc, []string{"https://cdn.example.com"}},
+		{UpgradeInsecureRequests, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.directive, func(t *testing.T) {
+			t.Parallel()
+			if got, ok := expanded[tt.directive]; !ok || !slices.Equal(got, tt.want) {
+				t.Errorf("expanded %s = %v, want %v", tt.directive, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("non-fetch directives are not added", func(t *testing.T) {
+		t.Parallel()
+		for _, d := range []string{BaseURI, FrameAncestors, FormAction} {
+			if _, ok := expanded[d]; ok {
+				t.Errorf("expanded policy unexpectedly contains %s", d)
+			}
+		}
+	})
+
+	t.Run("original is unchanged", func(t *testing.T) {
+		t.Parallel()
+		if got := p.Compile(); got != original {
+			t.Errorf("\nexpected: %s\ngot:      %s", original, got)
+		}
+	})
+}
+
+// TestPolicy_RelevantFor verifies that RelevantFor keeps only the directive
+// chain consulted for a resource type.
+func TestPolicy_RelevantFor(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ImgSrc, SchemeData)
+	p.Add(ScriptSrc, SourceNonce)
+	p.Add(StyleSrc, SourceSelf)
+	p.Add(FrameAncestors, SourceNone)
+	original := p.Compile()
+
+	tests := []struct {
+		resourceType string
+		expected     string
+	}{
+		{"image", "default-src 'self'; img-src data:"},
+		{"script", "default-src 'self'; script-src 'nonce-{{nonce}}'"},
+		{"Font", "default-src 'self'"},
+		{"unknown", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			t.Parallel()
+			if got := p.RelevantFor(tt.resourceType).Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+
+	if got := p.Compile(); got != original {
+		t.Errorf("original policy changed:\nexpected: %s\ngot:      %s", original, got)
+	}
+}
+
+// TestPolicy_RedundantDirectives verifies that only fetch directives whose
+// removal would not change the policy are reported.
+func TestPolicy_RedundantDirectives(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		expected []string
+	}{
+		{
+			name: "identical and differing",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf, "https://cdn.example.com")
+				p.Add(FontSrc, "https://cdn.example.com", SourceSelf)
+				p.Add(ImgSrc, SourceSelf)
+			},
+			expected: []string{FontSrc},
+		},
+		{
+			name: "chain of identical directives",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.Add(ScriptSrc, SourceSelf)
+				p.Add(ScriptSrcElem, SourceSelf)
+			},
+			expected: []string{ScriptSrc, ScriptSrcElem},
+		},
+		{
+			name: "intermediate directive differs",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.Add(ScriptSrc, SourceNonce)
+				p.Add(ScriptSrcElem, SourceSelf)
+			},
+			expected: nil,
+		},
+		{
+			name: "non-fetch directive",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.Add(FormAction, SourceSelf)
+			},
+			expected: nil,
+		},
+		{
+			name:     "no default-src",
+			setup:    func(p *Policy) { p.Add(FontSrc, SourceSelf) },
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			if got := p.RedundantDirectives(); !slices.Equal(got, tt.expected) {
+				t.Errorf("\nexpected: %v\ngot:      %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package csp
+
+import "strings"
+
+// HardenDefaults sets object-src 'none' and base-uri 'none' if those
+// directives are not already present. Existing values are left untouched.
+//
+// Disabling object-src blocks legacy plugin content (Flash, Java applets and
+// similar) that can execute script outside the script-src restrictions.
+// Restricting base-uri prevents an injected <base> element from redirecting
+// relative script URLs to an attacker-controlled origin, which would defeat
+// nonce- and hash-based policies.
+func (p *Policy) HardenDefaults() {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	for _, directive := range []string{ObjectSrc, BaseURI} {
+		if _, ok := p.directives[directive]; !ok {
+			p.addSourcesUnsafe(directive, []string{SourceNone})
+			changed = true
+		}
+	}
+	if changed {
+		p.invalidateCache()
+	}
+}
+
+// SetFrameAncestors replaces frame-ancestors with the given origins, which
+// control the pages allowed to embed the document and so protect against
+// clickjacking. Each origin must be 'self', 'none', a scheme source, or a
+// host source with an explicit scheme such as "https://partner.example.com".
+// Other values, including the bare wildcard "*", are dropped and reported by
+// Lint. If no acceptable origin remains, frame-ancestors is set to 'none'.
+func (p *Policy) SetFrameAncestors(origins ...string) {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clearNotesUnsafe(FrameAncestors)
+	delete(p.capped, FrameAncestors)
+	sources := make(map[string]struct{}, len(origins))
+	for _, origin := range origins {
+		o := strings.TrimSpace(origin)
+		if o == "" {
+			continue
+		}
+		if reason := frameAncestorProblem(o); reason != "" {
+			p.noteUnsafe(FrameAncestors, o, reason)
+			continue
+		}
+		p.addSourceUnsafe(FrameAncestors, sources, o)
+	}
+	if len(sources) == 0 {
+		p.addSourceUnsafe(FrameAncestors, sources, SourceNone)
+	}
+	p.directives[FrameAncestors] = sources
+	p.invalidateCache()
+}
+
+// frameAncestorProblem explains why origin is not acceptable for
+// frame-ancestors, or returns an empty string if it is.
+func frameAncestorProblem(origin string) string {
+	const wildcardProblem = "wildcard origin allows framing by any site; dropped"
+	if origin == "*" {
+		return wildcardProblem
+	}
+
+	switch ClassifySource(origin) {
+	case KindScheme:
+		return ""
+	case KindKeyword:
+		if origin == SourceSelf || origin == SourceNone {
+			return ""
+		}
+		return "keyword not allowed in frame-ancestors; dropped"
+	case KindHost:
+		_, hostPart, hasScheme := strings.Cut(origin, "://")
+		if !hasScheme {
+			return "origin must include a scheme; dropped"
+		}
+		if hostPart == "*" || strings.HasPrefix(hostPart, "*/") || strings.HasPrefix(hostPart, "*:") {
+			return wildcardProblem
+		}
+		if err := validateHostSource(origin); err != nil {
+			return err.Error() + "; dropped"
+		}
+		return ""
+	default:
+		return "not a valid origin; dropped"
+	}
+}
+
+// AllowWorkerEval adds 'unsafe-eval' to worker-src, so that eval is permitted
+// in workers without relaxing script-src. If worker-src is not set, it is
+// first seeded with the sources of the directive it currently falls back to
+// (child-src, script-src, then default-src), so that the set of permitted
+// worker scripts does not change. If none of them is set, workers are not
+// restricted at all, so worker-src is left unset and a finding is reported by
+// Lint instead. Lint also reports a finding if script-src allows eval as
+// well, since the scoping then has no effect.
+func (p *Policy) AllowWorkerEval() {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sources, ok := p.directives[WorkerSrc]
+	if !ok {
+		inherited := false
+		sources = make(map[string]struct{})
+		for _, directive := range workerFallback[1:] {
+			if fallback, set := p.directives[directive]; set {
+				for _, s := range sortedKeys(fallback) {
+					p.addSourceUnsafe(WorkerSrc, sources, s)
+				}
+				inherited = true
+				break
+			}
+		}
+		if !inherited {
+			p.noteOnceUnsafe(WorkerSrc, SourceUnsafeEval, "workers are unrestricted and may already use eval; 'unsafe-eval' not added")
+			return
+		}
+	}
+	p.addSourceUnsafe(WorkerSrc, sources, SourceUnsafeEval)
+	if _, added := sources[SourceUnsafeEval]; !added && !ok {
+		return
+	}
+	p.directives[WorkerSrc] = sources
+	p.invalidateCache()
+}
+
+// EnableStrictDynamic adds 'strict-dynamic' to script-src, and to
+// script-src-elem if it is set, so that scripts loaded by already trusted
+// scripts are trusted as well. 'strict-dynamic' only has an effect alongside
+// a nonce or hash source, so it is added only to directives that contain one;
+// for the others, a finding is reported by Lint instead.
+func (p *Policy) EnableStrictDynamic() {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	for _, directive := range []string{ScriptSrc, ScriptSrcElem} {
+		sources, ok := p.directives[directive]
+		if !ok && directive == ScriptSrcElem {
+			continue
+		}
+		if !hasNonceOrHash(sources) {
+			p.noteOnceUnsafe(directive, SourceStrictDynamic, "no nonce or hash source; 'strict-dynamic' not added")
+			continue
+		}
+		if _, ok := sources[SourceStrictDynamic]; !ok {
+			p.addSourceUnsafe(directive, sources, SourceStrictDynamic)
+			changed = true
+		}
+	}
+	if changed {
+		p.invalidateCache()
+	}
+}
+
+// hasNonceOrHash reports whether a source set contains a nonce or hash source.
+func hasNonceOrHash(sources map[string]struct{}) bool {
+	for s := range sources {
+		if kind := ClassifySource(s); kind == KindNonce || kind == KindHash {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsureSelf adds 'self' to each of the given directives that has sources but
+// neither 'self' nor 'none', so that same-origin resources are not blocked
+// when a directive lists only third-party hosts. Directives that are not set
+// or are empty are left untouched, as is any directive containing 'none'.
+func (p *Policy) EnsureSelf(directives ...string) {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	for _, directive := range directives {
+		sources := p.directives[normalizeDirective(directive)]
+		if len(sources) == 0 {
+			continue
+		}
+		_, hasSelf := sources[SourceSelf]
+		_, hasNone := sources[SourceNone]
+		if !hasSelf && !hasNone {
+			p.addSourceUnsafe(normalizeDirective(directive), sources, SourceSelf)
+			changed = true
+		}
+	}
+	if changed {
+		p.invalidateCache()
+	}
+}
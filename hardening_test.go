@@ -0,0 +1,384 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestPolicy_HardenDefaults verifies that HardenDefaults adds object-src and
+// base-uri only when they are unset.
+func TestPolicy_HardenDefaults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		expected string
+	}{
+		{
+			name:     "empty policy",
+			setup:    func(p *Policy) {},
+			expected: "base-uri 'none'; object-src 'none'",
+		},
+		{
+			name: "existing object-src is preserved",
+			setup: func(p *Policy) {
+				p.Add(ObjectSrc, SourceSelf)
+			},
+			expected: "base-uri 'none'; object-src 'self'",
+		},
+		{
+			name: "both already set",
+			setup: func(p *Policy) {
+				p.Add(ObjectSrc, SourceSelf)
+				p.Add(BaseURI, SourceSelf)
+			},
+			expected: "base-uri 'self'; object-src 'self'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			p.Compile() // Prime the cache to verify invalidation
+			p.HardenDefaults()
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_SetFrameAncestors verifies that SetFrameAncestors sets sorted
+// origins, defaults to 'none', and drops unsafe origins with a lint note.
+func TestPolicy_SetFrameAncestors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		origins  []string
+		expected string
+		wantLint bool
+	}{
+		{
+			name:     "no origins",
+			origins:  nil,
+			expected: "frame-ancestors 'none'",
+		},
+		{
+			name:     "origins are sorted",
+			origins:  []string{"https://b.example.com", SourceSelf, "https://a.example.com"},
+			expected: "frame-ancestors 'self' https://a.example.com https://b.example.com",
+		},
+		{
+			name:     "bare wildcard is dropped",
+			origins:  []string{"*", "https://a.example.com"},
+			expected: "frame-ancestors https://a.example.com",
+			wantLint: true,
+		},
+		{
+			name:     "scheme wildcard host is dropped",
+			origins:  []string{"https://*"},
+			expected: "frame-ancestors 'none'",
+			wantLint: true,
+		},
+		{
+			name:     "origin without scheme is dropped",
+			origins:  []string{"a.example.com", SourceSelf},
+			expected: "frame-ancestors 'self'",
+			wantLint: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(FrameAncestors, "https://old.example.com")
+			p.SetFrameAncestors(tt.origins...)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			if got := hasLintMessage(p.Lint(), FrameAncestors, "dropped"); got != tt.wantLint {
+				t.Errorf("dropped origin lint = %v, want %v", got, tt.wantLint)
+			}
+		})
+	}
+}
+
+// TestPolicy_AllowWorkerEval verifies that AllowWorkerEval scopes
+// 'unsafe-eval' to worker-src and leaves script-src unchanged.
+func TestPolicy_AllowWorkerEval(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		expected string
+		wantLint bool
+	}{
+		{
+			name:     "empty policy",
+			setup:    func(*Policy) {},
+			expected: "",
+		},
+		{
+			name:     "inherits script-src",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, SourceSelf) },
+			expected: "script-src 'self'; worker-src 'self' 'unsafe-eval'",
+		},
+		{
+			name: "inherits child-src before script-src",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf)
+				p.Add(ChildSrc, "https://a.com")
+			},
+			expected: "child-src https://a.com; script-src 'self'; worker-src 'unsafe-eval' https://a.com",
+		},
+		{
+			name: "existing worker-src is extended",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf)
+				p.Add(WorkerSrc, "https://w.com")
+			},
+			expected: "script-src 'self'; worker-src 'unsafe-eval' https://w.com",
+		},
+		{
+			name:     "script-src already allows eval",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, SourceSelf, SourceUnsafeEval) },
+			expected: "script-src 'self' 'unsafe-eval'; worker-src 'self' 'unsafe-eval'",
+			wantLint: true,
+		},
+		{
+			name:     "default-src already allows eval",
+			setup:    func(p *Policy) { p.Add(DefaultSrc, SourceUnsafeEval) },
+			expected: "default-src 'unsafe-eval'; worker-src 'unsafe-eval'",
+			wantLint: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			p.AllowWorkerEval()
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			gotLint := false
+			for _, r := range p.Lint() {
+				gotLint = gotLint || r.Check == CheckBroadEval
+			}
+			if gotLint != tt.wantLint {
+				t.Errorf("broad eval lint = %v, want %v", gotLint, tt.wantLint)
+			}
+			if wantNote := tt.expected == ""; hasLintMessage(p.Lint(), WorkerSrc, "not added") != wantNote {
+				t.Errorf("Lint() = %v, want unrestricted worker finding %v", p.Lint(), wantNote)
+			}
+		})
+	}
+}
+
+// TestPolicy_EnableStrictDynamic verifies that 'strict-dynamic' is added only
+// to script directives that contain a nonce or hash.
+func TestPolicy_EnableStrictDynamic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		expected string
+		wantLint string // Directive expected to have a finding.
+	}{
+		{
+			name:     "nonce in script-src",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, SourceSelf, SourceNonce) },
+			expected: "script-src 'self' 'strict-dynamic' 'nonce-{{nonce}}'",
+		},
+		{
+			name: "hash in script-src-elem only",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf)
+				p.Add(ScriptSrcElem, "'sha256-eHl6'")
+			},
+			expected: "script-src 'self'; script-src-elem 'sha256-eHl6' 'strict-dynamic'",
+			wantLint: ScriptSrc,
+		},
+		{
+			name:     "missing script-src",
+			setup:    func(p *Policy) { p.Add(DefaultSrc, SourceSelf) },
+			expected: "default-src 'self'",
+			wantLint: ScriptSrc,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			p.EnableStrictDynamic()
+			p.EnableStrictDynamic()
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			const msg = "'strict-dynamic' not added"
+			notes := 0
+			for _, r := range p.Lint() {
+				if strings.Contains(r.Message, msg) {
+					notes++
+				}
+			}
+			if tt.wantLint == "" && notes != 0 {
+				t.Errorf("Lint() = %v, want no strict-dynamic finding", p.Lint())
+			}
+			if tt.wantLint != "" && (notes != 1 || !hasLintMessage(p.Lint(), tt.wantLint, msg)) {
+				t.Errorf("Lint() = %v, want one strict-dynamic finding for %s", p.Lint(), tt.wantLint)
+			}
+		})
+	}
+}
+
+// TestPolicy_EnsureSelf verifies that 'self' is added only to directives that
+// have sources and do not contain 'self' or 'none'.
+func TestPolicy_EnsureSelf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		expected string
+	}{
+		{
+			name:     "host only",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, "https://cdn.example.com") },
+			expected: "script-src 'self' https://cdn.example.com",
+		},
+		{
+			name:     "none",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, SourceNone) },
+			expected: "script-src 'none'",
+		},
+		{
+			name:     "already self",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, SourceSelf, "https://cdn.example.com") },
+			expected: "script-src 'self' https://cdn.example.com",
+		},
+		{
+			name:     "unset",
+			setup:    func(p *Policy) { p.Add(ImgSrc, SchemeData) },
+			expected: "img-src data:",
+		},
+		{
+			name:     "empty",
+			setup:    func(p *Policy) { p.Add(UpgradeInsecureRequests) },
+			expected: "upgrade-insecure-requests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			p.EnsureSelf(ScriptSrc, UpgradeInsecureRequests)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_Hardening_Blocklist verifies that the hardening helpers drop
+// blocklisted sources and respect the source limit like Add.
+func TestPolicy_Hardening_Blocklist(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		blocked    string
+		maxSources int
+		apply      func(*Policy)
+		expected   string
+	}{
+		{
+			name:     "HardenDefaults",
+			blocked:  SourceNone,
+			apply:    func(p *Policy) { p.HardenDefaults() },
+			expected: "script-src 'self' https://a.com 'nonce-{{nonce}}'",
+		},
+		{
+			name:     "SetFrameAncestors",
+			blocked:  SourceSelf,
+			apply:    func(p *Policy) { p.SetFrameAncestors(SourceSelf, "https://b.com") },
+			expected: "frame-ancestors https://b.com; script-src https://a.com 'nonce-{{nonce}}'",
+		},
+		{
+			name:       "SetFrameAncestors limit",
+			maxSources: 3,
+			apply:      func(p *Policy) { p.SetFrameAncestors(SourceSelf, "https://b.com", "https://c.com", "https://d.com") },
+			expected:   "frame-ancestors 'self' https://b.com https://c.com; script-src 'self' https://a.com 'nonce-{{nonce}}'",
+		},
+		{
+			name:     "AllowWorkerEval",
+			blocked:  SourceUnsafeEval,
+			apply:    func(p *Policy) { p.AllowWorkerEval() },
+			expected: "script-src 'self' https://a.com 'nonce-{{nonce}}'",
+		},
+		{
+			name:     "EnableStrictDynamic",
+			blocked:  SourceStrictDynamic,
+			apply:    func(p *Policy) { p.EnableStrictDynamic() },
+			expected: "script-src 'self' https://a.com 'nonce-{{nonce}}'",
+		},
+		{
+			name:     "EnsureSelf",
+			blocked:  SourceSelf,
+			apply:    func(p *Policy) { p.Add(ImgSrc, "https://a.com"); p.EnsureSelf(ImgSrc) },
+			expected: "img-src https://a.com; script-src https://a.com 'nonce-{{nonce}}'",
+		},
+		{
+			name:       "EnsureSelf limit",
+			maxSources: 1,
+			apply:      func(p *Policy) { p.Add(ImgSrc, "https://a.com"); p.EnsureSelf(ImgSrc) },
+			expected:   "img-src https://a.com; script-src 'self' https://a.com 'nonce-{{nonce}}'",
+		},
+		{
+			name:     "EnableTrustedTypes",
+			blocked:  "app",
+			apply:    func(p *Policy) { p.EnableTrustedTypes("app") },
+			expected: "require-trusted-types-for 'script'; script-src 'self' https://a.com 'nonce-{{nonce}}'; trusted-types 'none'",
+		},
+		{
+			name:     "SetTrustedTypes",
+			blocked:  SourceAllowDuplicates,
+			apply:    func(p *Policy) { p.SetTrustedTypes([]string{"app"}, true) },
+			expected: "script-src 'self' https://a.com 'nonce-{{nonce}}'; trusted-types app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ScriptSrc, SourceSelf, SourceNonce, "https://a.com")
+			if tt.blocked != "" {
+				p.Blocklist(tt.blocked)
+			}
+			p.SetMaxSourcesPerDirective(tt.maxSources)
+			tt.apply(p)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			if tt.blocked != "" && !slices.ContainsFunc(p.Lint(), func(r LintResult) bool {
+				return r.Source == tt.blocked && strings.Contains(r.Message, "blocklisted")
+			}) {
+				t.Errorf("Lint() = %v, want a blocklist finding for %s", p.Lint(), tt.blocked)
+			}
+		})
+	}
+}
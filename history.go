@@ -0,0 +1,62 @@
+package csp
+
+import "maps"
+
+// Checkpoint saves a copy of the current directives and returns an id that
+// can later be passed to Restore. Ids increase monotonically and are never
+// reused, even after ClearCheckpoints.
+//
+// Every checkpoint holds a full copy of the directives until it is discarded
+// with ClearCheckpoints, so memory use grows with the number of checkpoints
+// and the size of the policy. Checkpoints are not carried over by Clone.
+func (p *Policy) Checkpoint() int {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.checkpoints == nil {
+		p.checkpoints = make(map[int]map[string]map[string]struct{})
+	}
+	p.lastCheckpoint++
+	p.checkpoints[p.lastCheckpoint] = cloneDirectives(p.directives)
+	return p.lastCheckpoint
+}
+
+// Restore reverts the directives to the state saved by the checkpoint with
+// the given id and reports whether such a checkpoint exists. The checkpoint
+// is kept, so it can be restored again. Settings other than the directives,
+// such as source limits or report-only marks, are not affected.
+func (p *Policy) Restore(id int) bool {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	saved, ok := p.checkpoints[id]
+	if !ok {
+		return false
+	}
+	p.directives = cloneDirectives(saved)
+	p.invalidateCache()
+	return true
+}
+
+// ClearCheckpoints discards all saved checkpoints, releasing their memory.
+func (p *Policy) ClearCheckpoints() {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.checkpoints = nil
+}
+
+// cloneDirectives returns a deep copy of a directive map.
+func cloneDirectives(directives map[string]map[string]struct{}) map[string]map[string]struct{} {
+	cloned := make(map[string]map[string]struct{}, len(directives))
+	for k, v := range directives {
+		cloned[k] = maps.Clone(v)
+	}
+	return cloned
+}
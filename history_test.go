@@ -0,0 +1,58 @@
+package csp
+
+import "testing"
+
+// TestPolicy_Checkpoint verifies that Restore reverts the directives to a
+// checkpoint and that ClearCheckpoints discards them.
+func TestPolicy_Checkpoint(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	first := p.Checkpoint()
+
+	p.Add(ScriptSrc, "https://cdn.example.com")
+	second := p.Checkpoint()
+
+	p.Remove(DefaultSrc)
+	p.Add(ImgSrc, SchemeData)
+	p.Compile() // Prime the cache to verify invalidation
+
+	if second <= first {
+		t.Fatalf("checkpoint ids not increasing: %d then %d", first, second)
+	}
+
+	if !p.Restore(first) {
+		t.Fatal("Restore(first) = false, want true")
+	}
+	if got := p.Compile(); got != "default-src 'self'" {
+		t.Errorf("after Restore(first): %q", got)
+	}
+
+	// Modifying the restored policy must not alter the checkpoint.
+	p.Add(DefaultSrc, "https://other.example.com")
+
+	if !p.Restore(second) {
+		t.Fatal("Restore(second) = false, want true")
+	}
+	expected := "default-src 'self'; script-src https://cdn.example.com"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+
+	if !p.Restore(first) || p.Compile() != "default-src 'self'" {
+		t.Error("checkpoint was modified after being restored")
+	}
+
+	if p.Restore(0) {
+		t.Error("Restore(0) = true, want false")
+	}
+
+	p.ClearCheckpoints()
+	if p.Restore(first) {
+		t.Error("Restore after ClearCheckpoints = true, want false")
+	}
+	if next := p.Checkpoint(); next <= second {
+		t.Errorf("checkpoint id %d reused after ClearCheckpoints", next)
+	}
+}
@@ -0,0 +1,146 @@
+package csp
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"strings"
+)
+
+// hashFuncs maps the hash algorithms supported by CSP to their hash
+// constructors.
+var hashFuncs = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// AddInlineScripts hashes the content of each inline script with every given
+// algorithm ("sha256", "sha384" or "sha512") and adds the resulting hash
+// sources to script-src. The content must match the text of the <script>
+// element exactly, including whitespace. The hash sources computed from the
+// same content are recorded as equivalent, so that PreferHashAlgorithm can
+// later reduce them to a single algorithm. If algos is empty, sha256 is used.
+// It returns an error, and adds nothing, if an algorithm is not supported.
+func (p *Policy) AddInlineScripts(algos []string, scripts ...string) error {
+	p.checkSealed()
+
+	if len(algos) == 0 {
+		algos = []string{"sha256"}
+	}
+	for _, algo := range algos {
+		if _, ok := hashFuncs[algo]; !ok {
+			return fmt.Errorf("unsupported hash algorithm: %q", algo)
+		}
+	}
+
+	groups := make([][]string, 0, len(scripts))
+	var sources []string
+	for _, script := range scripts {
+		group := make([]string, 0, len(algos))
+		for _, algo := range algos {
+			h := hashFuncs[algo]()
+			h.Write([]byte(script))
+			digest := base64.StdEncoding.EncodeToString(h.Sum(nil))
+			group = append(group, "'"+algo+"-"+digest+"'")
+		}
+		groups = append(groups, group)
+		sources = append(sources, group...)
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	p.Add(ScriptSrc, sources...)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Forget groups whose hashes have all been removed, and record each group
+	// once, so that repeated calls do not grow the list without bound.
+	p.pruneHashGroupsUnsafe()
+	for _, group := range groups {
+		if !slices.ContainsFunc(p.hashGroups, func(g []string) bool { return slices.Equal(g, group) }) {
+			p.hashGroups = append(p.hashGroups, group)
+		}
+	}
+	return nil
+}
+
+// pruneHashGroupsUnsafe forgets the hash groups recorded by AddInlineScripts
+// that have no hash left in script-src.
+// It assumes the caller holds the mutex.
+func (p *Policy) pruneHashGroupsUnsafe() {
+	present := p.directives[ScriptSrc]
+	p.hashGroups = slices.DeleteFunc(p.hashGroups, func(group []string) bool {
+		return !slices.ContainsFunc(group, func(h string) bool {
+			_, ok := present[h]
+			return ok
+		})
+	})
+}
+
+// PreferHashAlgorithm removes redundant hash sources from script-src, keeping
+// only the hash of the preferred algorithm for inline scripts that were added
+// with several algorithms. A single strong hash suffices to allow a script,
+// so the others only add bytes to the header.
+//
+// Whether two hashes belong to the same content cannot be determined from the
+// hashes themselves, so only the equivalences recorded by AddInlineScripts
+// are used. Hash sources added in any other way are left untouched, as are
+// scripts whose preferred hash is no longer present. It returns an error if
+// algo is not supported.
+func (p *Policy) PreferHashAlgorithm(algo string) error {
+	p.checkSealed()
+
+	if _, ok := hashFuncs[algo]; !ok {
+		return fmt.Errorf("unsupported hash algorithm: %q", algo)
+	}
+	prefix := "'" + algo + "-"
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sources := p.directives[ScriptSrc]
+	changed := false
+	for _, group := range p.hashGroups {
+		i := slices.IndexFunc(group, func(h string) bool { return strings.HasPrefix(h, prefix) })
+		if i < 0 {
+			continue
+		}
+		if _, ok := sources[group[i]]; !ok {
+			continue
+		}
+		for j, h := range group {
+			if _, ok := sources[h]; ok && j != i {
+				delete(sources, h)
+				changed = true
+			}
+		}
+	}
+	if changed {
+		p.invalidateCache()
+	}
+	return nil
+}
+
+// HashReader hashes everything read from r with the given algorithm
+// ("sha256", "sha384" or "sha512") and returns the hash source as formatted
+// by Hash, such as for asset files hashed at build time. The content is
+// streamed through the hash, so large files are not loaded into memory. It
+// returns an error if the algorithm is not supported or reading fails.
+func HashReader(algo string, r io.Reader) (string, error) {
+	newHash, ok := hashFuncs[algo]
+	if !ok {
+		return "", fmt.Errorf("unsupported hash algorithm: %q", algo)
+	}
+	h := newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", fmt.Errorf("read content: %w", err)
+	}
+	return Hash(algo, base64.StdEncoding.EncodeToString(h.Sum(nil))), nil
+}
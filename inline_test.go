@@ -0,0 +1,158 @@
+package csp
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestPolicy_AddInlineScripts verifies the hash sources computed for inline
+// scripts and the rejection of unsupported algorithms.
+func TestPolicy_AddInlineScripts(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	if err := p.AddInlineScripts([]string{"sha256", "sha384"}, "alert(1)"); err != nil {
+		t.Fatalf("AddInlineScripts() unexpected error: %v", err)
+	}
+	want := []string{
+		"'sha256-bhHHL3z2vDgxUt0W3dWQOrprscmda2Y5pLsLg4GF+pI='",
+		"'sha384-HT2E9NfWiuQ/w1PRai+hTyqW16NIoCGA/m8VQDUopfAtcz6YQjtsMmQd5uRbVDpW'",
+	}
+	if got := p.SourcesByKind(ScriptSrc, KindHash); !slices.Equal(got, want) {
+		t.Errorf("hash sources = %v, want %v", got, want)
+	}
+
+	if err := p.AddInlineScripts([]string{"md5"}, "alert(2)"); err == nil {
+		t.Error("AddInlineScripts() with md5 expected error, got none")
+	}
+	if err := p.AddInlineScripts(nil, "alert(1)"); err != nil {
+		t.Fatalf("AddInlineScripts() unexpected error: %v", err)
+	}
+	if got := p.SourcesByKind(ScriptSrc, KindHash); !slices.Equal(got, want) {
+		t.Errorf("hash sources after default algorithm = %v, want %v", got, want)
+	}
+}
+
+// TestPolicy_PreferHashAlgorithm verifies that only hashes paired by
+// AddInlineScripts are reduced to the preferred algorithm.
+func TestPolicy_PreferHashAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	const (
+		unpaired256 = "'sha256-dW5wYWlyZWQ='"
+		unpaired384 = "'sha384-dW5wYWlyZWQ='"
+	)
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, unpaired256, unpaired384)
+	if err := p.AddInlineScripts([]string{"sha256", "sha384", "sha512"}, "alert(1)", "alert(2)"); err != nil {
+		t.Fatalf("AddInlineScripts() unexpected error: %v", err)
+	}
+	p.Compile() // Prime the cache to verify invalidation
+
+	if err := p.PreferHashAlgorithm("sha384"); err != nil {
+		t.Fatalf("PreferHashAlgorithm() unexpected error: %v", err)
+	}
+
+	var got256, got384, got512 int
+	for _, h := range p.SourcesByKind(ScriptSrc, KindHash) {
+		switch h[:7] {
+		case "'sha256":
+			got256++
+		case "'sha384":
+			got384++
+		case "'sha512":
+			got512++
+		}
+	}
+	if got256 != 1 || got384 != 3 || got512 != 0 {
+		t.Errorf("hash counts = sha256:%d sha384:%d sha512:%d, want 1, 3, 0", got256, got384, got512)
+	}
+	if got := p.SourcesByKind(ScriptSrc, KindKeyword); !slices.Equal(got, []string{SourceSelf}) {
+		t.Errorf("keywords = %v, want [%s]", got, SourceSelf)
+	}
+
+	if err := p.PreferHashAlgorithm("sha1"); err == nil {
+		t.Error("PreferHashAlgorithm(sha1) expected error, got none")
+	}
+}
+
+// TestPolicy_AddInlineScripts_Repeated verifies that repeated calls record
+// each group of equivalent hashes once and forget groups that were removed.
+func TestPolicy_AddInlineScripts_Repeated(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	algos := []string{"sha256", "sha384"}
+	for range 100 {
+		if err := p.AddInlineScripts(algos, "alert(1)"); err != nil {
+			t.Fatalf("AddInlineScripts() unexpected error: %v", err)
+		}
+	}
+	if len(p.hashGroups) != 1 {
+		t.Errorf("recorded %d hash groups, want 1", len(p.hashGroups))
+	}
+
+	p.Remove(ScriptSrc)
+	if err := p.AddInlineScripts(algos, "alert(2)"); err != nil {
+		t.Fatalf("AddInlineScripts() unexpected error: %v", err)
+	}
+	if len(p.hashGroups) != 1 {
+		t.Errorf("recorded %d hash groups after Remove, want 1", len(p.hashGroups))
+	}
+}
+
+// TestHashReader verifies streamed hashing and its error cases.
+func TestHashReader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		algo     string
+		r        io.Reader
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "sha256",
+			algo:     "sha256",
+			r:        strings.NewReader("alert(1)"),
+			expected: "'sha256-bhHHL3z2vDgxUt0W3dWQOrprscmda2Y5pLsLg4GF+pI='",
+		},
+		{
+			name:     "empty content",
+			algo:     "sha256",
+			r:        strings.NewReader(""),
+			expected: "'sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU='",
+		},
+		{
+			name:    "unsupported algorithm",
+			algo:    "md5",
+			r:       strings.NewReader("alert(1)"),
+			wantErr: true,
+		},
+		{
+			name:    "read error",
+			algo:    "sha384",
+			r:       iotest.ErrReader(errors.New("boom")),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := HashReader(tt.algo, tt.r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HashReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
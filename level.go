@@ -0,0 +1,95 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+)
+
+// directiveLevels records the CSP level that introduced each directive.
+// Directives not listed, including those defined by other specifications
+// such as upgrade-insecure-requests, are treated as level 1.
+var directiveLevels = map[string]int{
+	BaseURI:        2,
+	ChildSrc:       2,
+	FormAction:     2,
+	FrameAncestors: 2,
+	PluginTypes:    2,
+
+	ManifestSrc:            3,
+	NavigateTo:             3,
+	PrefetchSrc:            3,
+	ReportTo:               3,
+	RequireTrustedTypesFor: 3,
+	ScriptSrcAttr:          3,
+	ScriptSrcElem:          3,
+	StyleSrcAttr:           3,
+	StyleSrcElem:           3,
+	TrustedTypes:           3,
+	WorkerSrc:              3,
+}
+
+// sourceLevels records the CSP level that introduced each keyword source.
+// Nonce and hash sources are level 2; all other sources are level 1.
+var sourceLevels = map[string]int{
+	SourceReportSample:   3,
+	SourceStrictDynamic:  3,
+	SourceUnsafeHashes:   3,
+	SourceWasmUnsafeEval: 3,
+}
+
+// CompileForLevel generates the CSP header string like Compile, restricted to
+// the features of the given CSP level, for user agents that do not support
+// later ones.
+//
+// Level 2 excludes the level 3 directives script-src-elem, script-src-attr,
+// style-src-elem, style-src-attr, worker-src, manifest-src, prefetch-src,
+// navigate-to, report-to, trusted-types and require-trusted-types-for, and the
+// level 3 sources 'strict-dynamic', 'unsafe-hashes', 'wasm-unsafe-eval' and
+// 'report-sample'. Level 1 additionally excludes the level 2 directives
+// base-uri, child-src, form-action, frame-ancestors and plugin-types, as well
+// as nonce and hash sources; levels below 1 are treated as level 1. Level 3
+// and above yield the result of Compile.
+//
+// A directive whose sources are all excluded is emitted without sources,
+// which blocks everything it governs rather than falling back to a more
+// permissive directive. The result is built on every call and is not cached.
+func (p *Policy) CompileForLevel(level int, nonce ...string) string {
+	if level >= 3 {
+		return p.Compile(nonce...)
+	}
+	level = max(level, 1)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys := make([]string, 0, len(p.directives))
+	for _, key := range sortedKeys(p.directives) {
+		if max(directiveLevels[key], 1) <= level {
+			keys = append(keys, key)
+		}
+	}
+
+	var b strings.Builder
+	hasNonce := writeDirectives(&b, p.displayNames, keys, func(key string) []string {
+		return slices.DeleteFunc(sortedSources(p.directives[key], slices.Sort[[]string]), func(s string) bool {
+			return sourceLevel(s) > level
+		})
+	})
+	if !hasNonce {
+		return b.String()
+	}
+	return p.injectNonce(b.String(), nonce)
+}
+
+// sourceLevel returns the CSP level that introduced the source s.
+func sourceLevel(s string) int {
+	if l, ok := sourceLevels[s]; ok {
+		return l
+	}
+	switch ClassifySource(s) {
+	case KindNonce, KindHash:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,59 @@
+package csp
+
+import "testing"
+
+// TestPolicy_CompileForLevel verifies that features newer than the requested
+// level are left out of the output.
+func TestPolicy_CompileForLevel(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceNonce, SourceStrictDynamic, SourceWasmUnsafeEval)
+	p.Add(ScriptSrcElem, SourceSelf)
+	p.Add(FrameAncestors, SourceNone)
+	p.Add(ImgSrc, SourceSelf, SourceReportSample)
+
+	tests := []struct {
+		name     string
+		level    int
+		expected string
+	}{
+		{
+			name:  "level 3",
+			level: 3,
+			expected: "default-src 'self'; frame-ancestors 'none'; img-src 'report-sample' 'self'; " +
+				"script-src 'strict-dynamic' 'wasm-unsafe-eval' 'nonce-abc'; script-src-elem 'self'",
+		},
+		{
+			name:     "level 2",
+			level:    2,
+			expected: "default-src 'self'; frame-ancestors 'none'; img-src 'self'; script-src 'nonce-abc'",
+		},
+		{
+			name:     "level 1",
+			level:    1,
+			expected: "default-src 'self'; img-src 'self'; script-src",
+		},
+		{
+			name:     "below level 1",
+			level:    0,
+			expected: "default-src 'self'; img-src 'self'; script-src",
+		},
+		{
+			name:  "above level 3",
+			level: 4,
+			expected: "default-src 'self'; frame-ancestors 'none'; img-src 'report-sample' 'self'; " +
+				"script-src 'strict-dynamic' 'wasm-unsafe-eval' 'nonce-abc'; script-src-elem 'self'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.CompileForLevel(tt.level, "abc"); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package csp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SetMaxSourcesPerDirective limits the number of sources a single directive
+// may hold. Once a directive reaches the limit, further sources passed to Add,
+// Set, Merge or any other method that adds sources are rejected and reported
+// by Lint. Sources already present are
+// kept even if they exceed a newly lowered limit. A value of zero or less
+// removes the limit.
+func (p *Policy) SetMaxSourcesPerDirective(n int) {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.maxSources = max(n, 0)
+}
+
+// lintSourceLimit reports directives for which sources were rejected because
+// of the per-directive source limit.
+func lintSourceLimit(p *Policy) []LintResult {
+	if len(p.capped) == 0 {
+		return nil
+	}
+	results := make([]LintResult, 0, len(p.capped))
+	for _, directive := range sortedKeys(p.capped) {
+		results = append(results, LintResult{
+			Directive: directive,
+			Message:   fmt.Sprintf("source limit of %d reached; %d source(s) rejected", p.maxSources, p.capped[directive]),
+		})
+	}
+	return results
+}
+
+// SizeDelta returns the difference in compiled header length, in bytes,
+// between the policy and other. A positive value means the policy is larger.
+// A nil other is treated as an empty policy. Both policies are compiled
+// without a nonce, so the result is independent of per-request nonce values.
+func (p *Policy) SizeDelta(other *Policy) int {
+	size := len(p.Compile())
+	if other == nil {
+		return size
+	}
+	return size - len(other.Compile())
+}
+
+// CompileLimited compiles the policy like Compile and returns an error if the
+// result exceeds maxBytes, so that a header too large for a proxy or browser
+// is rejected instead of being truncated downstream. The error reports the
+// actual size. A maxBytes of zero or less disables the check.
+func (p *Policy) CompileLimited(maxBytes int, nonce ...string) (string, error) {
+	header := p.Compile(nonce...)
+	if maxBytes > 0 && len(header) > maxBytes {
+		return "", fmt.Errorf("compiled policy is %d bytes, exceeding the limit of %d", len(header), maxBytes)
+	}
+	return header, nil
+}
+
+// DefaultNonceLength is the nonce length, in characters, assumed by
+// MaxCompiledSize when none is given: the standard base64 encoding of 16
+// random bytes.
+const DefaultNonceLength = 24
+
+// MaxCompiledSize returns the length, in bytes, of the compiled policy with a
+// nonce of nonceLen characters substituted for every nonce placeholder, as an
+// upper bound for header size budgets that holds for any nonce of at most
+// that length. A nonceLen of zero or less assumes DefaultNonceLength. For
+// policies without a nonce placeholder, it is the length of Compile.
+func (p *Policy) MaxCompiledSize(nonceLen int) int {
+	cache, _, needsNonce := p.peekCache()
+	if !needsNonce {
+		return len(cache)
+	}
+	if nonceLen <= 0 {
+		nonceLen = DefaultNonceLength
+	}
+	nonce := strings.Repeat("x", nonceLen)
+	return len(replaceNoncePlaceholders(cache, func(string) string { return nonce }))
+}
+
+// CompileSplitBySize compiles the policy into one or more header values, each
+// at most maxBytes long where possible, to be sent as separate
+// Content-Security-Policy headers. Browsers enforce every header, so a
+// resource must be allowed by all of them. Splitting is therefore restricted
+// to directives that do not affect each other: directives linked by the
+// fallback table, such as default-src and every fetch directive when
+// default-src is set, always stay in the same value, since moving one of them
+// to another header would change what the others allow. report-uri and
+// report-to are repeated in every value so that all violations are reported.
+//
+// A group of linked directives that alone exceeds maxBytes is emitted in a
+// value of its own, which then exceeds the limit. If the whole policy fits,
+// or maxBytes is zero or less, a single value is returned. An empty policy
+// yields nil.
+func (p *Policy) CompileSplitBySize(maxBytes int, nonce string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.directives) == 0 {
+		return nil
+	}
+	nonces := []string{nonce}
+	compile := func(keys []string) string {
+		sorted := slices.Clone(keys)
+		slices.Sort(sorted)
+		return p.compileKeysUnsafe(sorted, slices.Sort[[]string], nonces)
+	}
+
+	whole := p.compileKeysUnsafe(sortedKeys(p.directives), slices.Sort[[]string], nonces)
+	if maxBytes <= 0 || len(whole) <= maxBytes {
+		return []string{whole}
+	}
+
+	groups, reporting := p.splitGroupsUnsafe()
+	var parts [][]string
+	for _, group := range groups {
+		placed := false
+		for i, part := range parts {
+			if candidate := slices.Concat(part, group); len(compile(slices.Concat(candidate, reporting))) <= maxBytes {
+				parts[i] = candidate
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			parts = append(parts, group)
+		}
+	}
+
+	headers := make([]string, 0, len(parts))
+	for _, part := range parts {
+		headers = append(headers, compile(slices.Concat(part, reporting)))
+	}
+	return headers
+}
+
+// splitGroupsUnsafe partitions the directives for CompileSplitBySize into
+// groups that must stay in the same header, in order of their first
+// directive, and returns the reporting directives separately.
+// It assumes the caller holds the mutex.
+func (p *Policy) splitGroupsUnsafe() ([][]string, []string) {
+	var reporting, others []string
+	for _, key := range sortedKeys(p.directives) {
+		if key == ReportURI || key == ReportTo {
+			reporting = append(reporting, key)
+		} else {
+			others = append(others, key)
+		}
+	}
+
+	// Label each directive with a group and merge the groups of directives
+	// that appear in each other's fallback chain.
+	group := make(map[string]int, len(others))
+	for i, key := range others {
+		group[key] = i
+	}
+	for _, key := range others {
+		for _, d := range fallbackChain(key) {
+			target, ok := group[d]
+			if !ok || target == group[key] {
+				continue
+			}
+			old := group[key]
+			for k, g := range group {
+				if g == old {
+					group[k] = target
+				}
+			}
+		}
+	}
+
+	var groups [][]string
+	index := make(map[int]int)
+	for _, key := range others {
+		i, ok := index[group[key]]
+		if !ok {
+			i = len(groups)
+			index[group[key]] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], key)
+	}
+	return groups, reporting
+}
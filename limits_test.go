@@ -0,0 +1,281 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestPolicy_SetMaxSourcesPerDirective verifies that sources beyond the
+// configured limit are rejected by Add, Set and Merge, and reported by Lint.
+func TestPolicy_SetMaxSourcesPerDirective(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		limit    int
+		setup    func(*Policy)
+		expected string
+		wantLint bool
+	}{
+		{
+			name:  "add beyond limit is rejected",
+			limit: 2,
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, "https://a.com", "https://b.com")
+				p.Add(ScriptSrc, "https://c.com")
+			},
+			expected: "script-src https://a.com https://b.com",
+			wantLint: true,
+		},
+		{
+			name:  "duplicate source does not count",
+			limit: 2,
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, "https://a.com", "https://b.com")
+				p.Add(ScriptSrc, "https://a.com")
+			},
+			expected: "script-src https://a.com https://b.com",
+			wantLint: false,
+		},
+		{
+			name:  "set keeps the first sources in argument order",
+			limit: 1,
+			setup: func(p *Policy) {
+				p.Set(ScriptSrc, "https://b.com", "https://a.com")
+			},
+			expected: "script-src https://b.com",
+			wantLint: true,
+		},
+		{
+			name:  "merge respects limit",
+			limit: 1,
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf)
+				other := New()
+				other.Add(ScriptSrc, "https://a.com")
+				p.Merge(other)
+			},
+			expected: "script-src 'self'",
+			wantLint: true,
+		},
+		{
+			name:  "zero means unlimited",
+			limit: 0,
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, "https://a.com", "https://b.com", "https://c.com")
+			},
+			expected: "script-src https://a.com https://b.com https://c.com",
+			wantLint: false,
+		},
+		{
+			name:  "remove clears the report",
+			limit: 1,
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, "https://a.com", "https://b.com")
+				p.Remove(ScriptSrc)
+			},
+			expected: "",
+			wantLint: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.SetMaxSourcesPerDirective(tt.limit)
+			tt.setup(p)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			if got := hasLintMessage(p.Lint(), ScriptSrc, "source limit"); got != tt.wantLint {
+				t.Errorf("source limit lint = %v, want %v", got, tt.wantLint)
+			}
+		})
+	}
+}
+
+// TestPolicy_SizeDelta verifies the header size difference between policies.
+func TestPolicy_SizeDelta(t *testing.T) {
+	t.Parallel()
+
+	base := New()
+	base.Add(DefaultSrc, SourceSelf)
+
+	extended := base.Clone()
+	extended.Add(DefaultSrc, "https://a.com")
+
+	tests := []struct {
+		name  string
+		p     *Policy
+		other *Policy
+		want  int
+	}{
+		{"one added host", extended, base, len(" https://a.com")},
+		{"one removed host", base, extended, -len(" https://a.com")},
+		{"identical", base, base.Clone(), 0},
+		{"nil other", base, nil, len("default-src 'self'")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.p.SizeDelta(tt.other); got != tt.want {
+				t.Errorf("SizeDelta() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicy_CompileLimited verifies that CompileLimited rejects policies that
+// exceed the size limit and reports their size.
+func TestPolicy_CompileLimited(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf) // 18 bytes
+	p.Add(ScriptSrc, SourceNonce)
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		nonce    []string
+		wantErr  string
+	}{
+		{"within limit", 100, nil, ""},
+		{"exact limit", len("default-src 'self'; script-src 'nonce-abc'"), []string{"abc"}, ""},
+		{"nonce pushes over limit", 40, []string{"abcdefgh"}, "is 47 bytes"},
+		{"over limit", 10, nil, "exceeding the limit of 10"},
+		{"no limit", 0, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := p.CompileLimited(tt.maxBytes, tt.nonce...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CompileLimited() error = %v", err)
+				}
+				if want := p.Compile(tt.nonce...); got != want {
+					t.Errorf("\nexpected: %s\ngot:      %s", want, got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CompileLimited() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestPolicy_CompileSplitBySize verifies that independent directives are
+// split into header values within the limit while linked directives and
+// reporting directives are kept together.
+func TestPolicy_CompileSplitBySize(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceNonce, "https://scripts.example.com")
+	p.Add(FormAction, "https://forms-a.example.com", "https://forms-b.example.com")
+	p.Add(FrameAncestors, "https://partner-a.example.com", "https://partner-b.example.com")
+	p.Add(ReportURI, "/csp")
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		expected []string
+	}{
+		{
+			name:     "fits in one",
+			maxBytes: 1000,
+			expected: []string{p.Compile("abc")},
+		},
+		{
+			name:     "no limit",
+			maxBytes: 0,
+			expected: []string{p.Compile("abc")},
+		},
+		{
+			name:     "split in two",
+			maxBytes: 160,
+			expected: []string{
+				"default-src 'self'; form-action https://forms-a.example.com https://forms-b.example.com; " +
+					"report-uri /csp; script-src https://scripts.example.com 'nonce-abc'",
+				"frame-ancestors https://partner-a.example.com https://partner-b.example.com; report-uri /csp",
+			},
+		},
+		{
+			name:     "oversized group stands alone",
+			maxBytes: 10,
+			expected: []string{
+				"default-src 'self'; report-uri /csp; script-src https://scripts.example.com 'nonce-abc'",
+				"form-action https://forms-a.example.com https://forms-b.example.com; report-uri /csp",
+				"frame-ancestors https://partner-a.example.com https://partner-b.example.com; report-uri /csp",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := p.CompileSplitBySize(tt.maxBytes, "abc")
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("\nexpected: %q\ngot:      %q", tt.expected, got)
+			}
+			if tt.maxBytes < 100 {
+				return
+			}
+			for _, header := range got {
+				if len(header) > tt.maxBytes {
+					t.Errorf("header of %d bytes exceeds limit of %d: %s", len(header), tt.maxBytes, header)
+				}
+			}
+		})
+	}
+
+	if got := New().CompileSplitBySize(100, "abc"); got != nil {
+		t.Errorf("CompileSplitBySize() of empty policy = %q, want nil", got)
+	}
+}
+
+// TestPolicy_MaxCompiledSize verifies that the size bound accounts for every
+// nonce placeholder and covers an actual compilation.
+func TestPolicy_MaxCompiledSize(t *testing.T) {
+	t.Parallel()
+
+	withNonce := New()
+	withNonce.Add(ScriptSrc, SourceSelf, SourceNonce)
+	withNonce.Add(StyleSrc, NamedNonce("styles"))
+
+	withoutNonce := New()
+	withoutNonce.Add(DefaultSrc, SourceSelf)
+
+	standard := strings.Repeat("n", DefaultNonceLength)
+
+	tests := []struct {
+		name     string
+		policy   *Policy
+		nonceLen int
+		expected int
+	}{
+		{"default length", withNonce, 0, len(withNonce.Compile(standard))},
+		{"explicit length", withNonce, 8, len(withNonce.Compile("12345678"))},
+		{"no nonce", withoutNonce, 0, len(withoutNonce.Compile())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.policy.MaxCompiledSize(tt.nonceLen); got != tt.expected {
+				t.Errorf("MaxCompiledSize(%d) = %d, want %d", tt.nonceLen, got, tt.expected)
+			}
+		})
+	}
+
+	if bound, actual := withNonce.MaxCompiledSize(0), len(withNonce.Compile("c2hvcnQ=")); bound <= actual {
+		t.Errorf("MaxCompiledSize(0) = %d, want more than %d", bound, actual)
+	}
+}
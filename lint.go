@@ -0,0 +1,662 @@
+package csp
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// LintCheck identifies one of the checks run by Lint.
+type LintCheck string
+
+// These are the checks run by Lint. Any of them can be turned off with
+// DisableLintChecks.
+const (
+	CheckMissingDefaultSrc      LintCheck = "missing-default-src"
+	CheckSourceLimit            LintCheck = "source-limit"
+	CheckDroppedSource          LintCheck = "dropped-source"
+	CheckReportSample           LintCheck = "report-sample"
+	CheckTrailingSlash          LintCheck = "trailing-slash"
+	CheckReportEndpointBlocked  LintCheck = "report-endpoint-blocked"
+	CheckMissingFrameAncestors  LintCheck = "missing-frame-ancestors"
+	CheckBroadEval              LintCheck = "broad-eval"
+	CheckMetaUnsupported        LintCheck = "meta-unsupported"
+	CheckRedundantSelf          LintCheck = "redundant-self"
+	CheckWildcardPortOrScheme   LintCheck = "wildcard-port-or-scheme"
+	CheckNoneConflict           LintCheck = "none-conflict"
+	CheckEmptyDirective         LintCheck = "empty-directive"
+	CheckInlineStyleBlocked     LintCheck = "inline-style-blocked"
+	CheckFrameAncestorsWildcard LintCheck = "frame-ancestors-wildcard"
+	CheckUpgradeInsecureHTTP    LintCheck = "upgrade-insecure-http"
+	CheckMissingBaseURI         LintCheck = "missing-base-uri"
+	CheckSelfReportEndpoint     LintCheck = "self-report-endpoint"
+	CheckIgnoredUnsafeInline    LintCheck = "ignored-unsafe-inline"
+)
+
+// CheckNonConforming identifies the findings of Conforms. It is not run by
+// Lint.
+const CheckNonConforming LintCheck = "non-conforming"
+
+// CheckReportOnlyMismatch identifies the findings of CompareEnforceReportOnly.
+// It is not run by Lint.
+const CheckReportOnlyMismatch LintCheck = "report-only-mismatch"
+
+// Severity ranks lint findings by how likely they are to cause a problem.
+type Severity int
+
+// These are the severities assigned to lint findings, in increasing order.
+const (
+	SeverityInfo    Severity = iota // Suggestion; the policy works as written.
+	SeverityWarning                 // Likely unintended configuration.
+	SeverityError                   // The policy does not behave as written.
+)
+
+// String returns a lowercase name for the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Context identifies how a policy is delivered to the browser, which
+// determines the directives that take effect.
+type Context int
+
+// These are the delivery contexts recognized by LintForContext.
+const (
+	ContextHeader Context = iota // Content-Security-Policy response header.
+	ContextMeta                  // <meta http-equiv="Content-Security-Policy"> element.
+)
+
+// LintResult describes a single finding reported by Lint.
+type LintResult struct {
+	Check     LintCheck // Check that produced the finding.
+	Severity  Severity  // Severity of the finding.
+	Directive string    // Directive the finding applies to; empty for policy-wide findings.
+	Source    string    // Offending source, if the finding concerns a single source.
+	Message   string    // Human-readable description of the finding.
+}
+
+// String returns the finding formatted as "directive: message", or just the
+// message for policy-wide findings.
+func (r LintResult) String() string {
+	if r.Directive == "" {
+		return r.Message
+	}
+	return r.Directive + ": " + r.Message
+}
+
+// FilterBySeverity returns the findings whose severity is at least threshold,
+// in their original order. It returns nil if none qualify.
+func FilterBySeverity(results []LintResult, threshold Severity) []LintResult {
+	var filtered []LintResult
+	for _, r := range results {
+		if r.Severity >= threshold {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// lintCheck inspects a policy and returns its findings.
+// Checks are called with the policy's read lock held.
+type lintCheck func(p *Policy) []LintResult
+
+// namedLintCheck pairs a check with the identifier it reports under and the
+// severity of its findings. A check may raise the severity of an individual
+// finding by setting it explicitly.
+type namedLintCheck struct {
+	id       LintCheck
+	severity Severity
+	check    lintCheck
+}
+
+// lintChecks lists the checks run by Lint, in reporting order.
+var lintChecks = []namedLintCheck{
+	{CheckMissingDefaultSrc, SeverityWarning, lintMissingDefaultSrc},
+	{CheckSourceLimit, SeverityWarning, lintSourceLimit},
+	{CheckDroppedSource, SeverityWarning, lintNotes},
+	{CheckReportSample, SeverityInfo, lintReportSample},
+	{CheckTrailingSlash, SeverityInfo, lintTrailingSlash},
+	{CheckReportEndpointBlocked, SeverityWarning, lintReportEndpointBlocked},
+	{CheckMissingFrameAncestors, SeverityInfo, lintMissingFrameAncestors},
+	{CheckBroadEval, SeverityWarning, lintBroadEval},
+	{CheckRedundantSelf, SeverityInfo, lintRedundantSelf},
+	{CheckWildcardPortOrScheme, SeverityInfo, lintWildcardPortOrScheme},
+	{CheckNoneConflict, SeverityError, lintNoneConflict},
+	{CheckEmptyDirective, SeverityInfo, lintEmptyDirective},
+	{CheckInlineStyleBlocked, SeverityWarning, lintInlineStyleBlocked},
+	{CheckFrameAncestorsWildcard, SeverityWarning, lintFrameAncestorsWildcard},
+	{CheckUpgradeInsecureHTTP, SeverityWarning, lintUpgradeInsecureHTTP},
+	{CheckMissingBaseURI, SeverityWarning, lintMissingBaseURI},
+	{CheckSelfReportEndpoint, SeverityInfo, lintSelfReportEndpoint},
+	{CheckIgnoredUnsafeInline, SeverityInfo, lintIgnoredUnsafeInline},
+}
+
+// metaLintChecks lists the additional checks run by LintForContext for
+// policies delivered in a <meta> element.
+var metaLintChecks = []namedLintCheck{
+	{CheckMetaUnsupported, SeverityWarning, lintMetaUnsupported},
+}
+
+// Lint inspects the policy for configuration mistakes that are syntactically
+// valid but likely unintended. Unlike Strict, the findings are advisory and
+// do not indicate a malformed header. Checks disabled with DisableLintChecks
+// are skipped. It returns nil if nothing was found.
+func (p *Policy) Lint() []LintResult {
+	return p.LintForContext(ContextHeader)
+}
+
+// LintForContext is like Lint, but additionally checks for directives that
+// have no effect in the given delivery context. In ContextMeta, it warns about
+// frame-ancestors, report-to, report-uri and sandbox, which browsers ignore
+// when the policy is delivered in a <meta> element.
+func (p *Policy) LintForContext(ctx Context) []LintResult {
+	checks := lintChecks
+	if ctx == ContextMeta {
+		checks = slices.Concat(lintChecks, metaLintChecks)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var results []LintResult
+	for _, c := range checks {
+		if _, disabled := p.disabledChecks[c.id]; disabled {
+			continue
+		}
+		for _, r := range c.check(p) {
+			r.Check = c.id
+			r.Severity = max(r.Severity, c.severity)
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// DisableLintChecks turns off the given checks for subsequent calls to Lint.
+func (p *Policy) DisableLintChecks(checks ...LintCheck) {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.disabledChecks == nil {
+		p.disabledChecks = make(map[LintCheck]struct{}, len(checks))
+	}
+	for _, c := range checks {
+		p.disabledChecks[c] = struct{}{}
+	}
+}
+
+// EnableLintChecks turns the given checks back on after DisableLintChecks.
+func (p *Policy) EnableLintChecks(checks ...LintCheck) {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, c := range checks {
+		delete(p.disabledChecks, c)
+	}
+}
+
+// HasDefaultSrc reports whether the policy sets default-src.
+func (p *Policy) HasDefaultSrc() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	_, ok := p.directives[DefaultSrc]
+	return ok
+}
+
+// lintMissingDefaultSrc warns when default-src, the fallback for all fetch
+// directives, is absent.
+func lintMissingDefaultSrc(p *Policy) []LintResult {
+	if _, ok := p.directives[DefaultSrc]; ok {
+		return nil
+	}
+	return []LintResult{{
+		Message: "no default-src set; directives without an explicit value and no fallback are unrestricted",
+	}}
+}
+
+// reportSampleDirectives lists the directives in which 'report-sample' has an
+// effect. default-src is included because script and style directives may
+// fall back to it.
+var reportSampleDirectives = []string{
+	DefaultSrc,
+	ScriptSrc, ScriptSrcAttr, ScriptSrcElem,
+	StyleSrc, StyleSrcAttr, StyleSrcElem,
+}
+
+// lintReportSample warns about 'report-sample' in directives where it is inert.
+func lintReportSample(p *Policy) []LintResult {
+	var results []LintResult
+	for _, directive := range sortedKeys(p.directives) {
+		if _, ok := p.directives[directive][SourceReportSample]; !ok {
+			continue
+		}
+		if slices.Contains(reportSampleDirectives, directive) {
+			continue
+		}
+		results = append(results, LintResult{
+			Directive: directive,
+			Source:    SourceReportSample,
+			Message:   "'report-sample' only has an effect in script and style directives",
+		})
+	}
+	return results
+}
+
+// lintTrailingSlash warns when a directive contains the same host source both
+// with and without a trailing slash. The two forms match differently, since a
+// path ending in "/" is a prefix match while one without is an exact match,
+// but listing both is usually an accident. The sources are not merged.
+func lintTrailingSlash(p *Policy) []LintResult {
+	var results []LintResult
+	for _, directive := range sortedKeys(p.directives) {
+		sources := p.directives[directive]
+		for _, s := range sortedKeys(sources) {
+			trimmed, ok := strings.CutSuffix(s, "/")
+			if !ok || ClassifySource(s) != KindHost {
+				continue
+			}
+			if _, dup := sources[trimmed]; !dup {
+				continue
+			}
+			results = append(results, LintResult{
+				Directive: directive,
+				Source:    s,
+				Message:   fmt.Sprintf("both %q and %q are present; they match different paths", trimmed, s),
+			})
+		}
+	}
+	return results
+}
+
+// lintReportEndpointBlocked warns when an absolute report-uri endpoint is not
+// allowed by connect-src, or default-src in its absence, since some browsers
+// apply the policy to violation reports. Relative endpoints and report-to
+// groups, whose endpoint URLs are not part of the policy, are not checked.
+func lintReportEndpointBlocked(p *Policy) []LintResult {
+	var results []LintResult
+	for _, endpoint := range sortedKeys(p.directives[ReportURI]) {
+		u, err := url.Parse(endpoint)
+		if err != nil || !u.IsAbs() || u.Hostname() == "" {
+			continue
+		}
+		if p.coversUnsafe(ConnectSrc, u) {
+			continue
+		}
+		results = append(results, LintResult{
+			Directive: ReportURI,
+			Source:    endpoint,
+			Message:   "report endpoint is not allowed by connect-src; reports may be blocked",
+		})
+	}
+	return results
+}
+
+// lintSelfReportEndpoint notes absolute report-uri endpoints on the document
+// origin set by WithOrigin. If the endpoint serves the same policy and its
+// own responses violate it, each report can trigger further reports.
+// Relative endpoints and report-to groups are not checked, as for
+// lintReportEndpointBlocked. It reports nothing if no origin is set.
+func lintSelfReportEndpoint(p *Policy) []LintResult {
+	if p.origin == "" {
+		return nil
+	}
+	var results []LintResult
+	for _, endpoint := range sortedKeys(p.directives[ReportURI]) {
+		u, err := url.Parse(endpoint)
+		if err != nil || !u.IsAbs() || u.Host == "" {
+			continue
+		}
+		if origin, ok := canonicalOrigin(u.Scheme + "://" + u.Host); !ok || origin != p.origin {
+			continue
+		}
+		results = append(results, LintResult{
+			Directive: ReportURI,
+			Source:    endpoint,
+			Message:   "report endpoint is on the document origin; violations in its own responses could loop",
+		})
+	}
+	return results
+}
+
+// frameAncestorsTriggers lists the security directives whose presence
+// suggests that the policy is meant to protect the page, so that a missing
+// frame-ancestors is worth pointing out.
+var frameAncestorsTriggers = []string{DefaultSrc, ObjectSrc, ScriptSrc}
+
+// lintMissingFrameAncestors suggests frame-ancestors for policies that set
+// default-src, object-src or script-src but lack it. Without it, protection
+// against clickjacking relies on the legacy X-Frame-Options header, which
+// frame-ancestors supersedes.
+func lintMissingFrameAncestors(p *Policy) []LintResult {
+	if _, ok := p.directives[FrameAncestors]; ok {
+		return nil
+	}
+	for _, directive := range frameAncestorsTriggers {
+		if _, ok := p.directives[directive]; ok {
+			return []LintResult{{
+				Message: "consider frame-ancestors to prevent clickjacking (replaces X-Frame-Options)",
+			}}
+		}
+	}
+	return nil
+}
+
+// lintBroadEval warns when worker-src allows 'unsafe-eval' but the main script
+// context does too, either through script-src or, in its absence, default-src.
+// Scoping eval to workers then has no effect.
+func lintBroadEval(p *Policy) []LintResult {
+	if _, ok := p.directives[WorkerSrc][SourceUnsafeEval]; !ok {
+		return nil
+	}
+	for _, directive := range fallbackChain(ScriptSrc) {
+		sources, ok := p.directives[directive]
+		if !ok {
+			continue
+		}
+		if _, eval := sources[SourceUnsafeEval]; !eval {
+			return nil
+		}
+		return []LintResult{{
+			Directive: directive,
+			Source:    SourceUnsafeEval,
+			Message:   "'unsafe-eval' is allowed outside workers; scoping it to worker-src has no effect",
+		}}
+	}
+	return nil
+}
+
+// lintRedundantSelf warns about host sources that equal the document origin
+// set by WithOrigin in directives that also contain 'self', which already
+// allows that origin. It reports nothing if no origin is set.
+func lintRedundantSelf(p *Policy) []LintResult {
+	if p.origin == "" {
+		return nil
+	}
+	var results []LintResult
+	for _, directive := range sortedKeys(p.directives) {
+		sources := p.directives[directive]
+		if _, ok := sources[SourceSelf]; !ok {
+			continue
+		}
+		for _, s := range sortedKeys(sources) {
+			if ClassifySource(s) != KindHost {
+				continue
+			}
+			if origin, ok := canonicalOrigin(s); !ok || origin != p.origin {
+				continue
+			}
+			results = append(results, LintResult{
+				Directive: directive,
+				Source:    s,
+				Message:   "host source is the document origin, which 'self' already allows",
+			})
+		}
+	}
+	return results
+}
+
+// lintWildcardPortOrScheme flags host sources with a wildcard port, such as
+// https://example.com:*, or a wildcard scheme, such as *://example.com. Both
+// are valid but allow more than the host alone suggests: every port of the
+// host, or every scheme including insecure ones.
+func lintWildcardPortOrScheme(p *Policy) []LintResult {
+	var results []LintResult
+	for _, directive := range sortedKeys(p.directives) {
+		for _, s := range sortedKeys(p.directives[directive]) {
+			if ClassifySource(s) != KindHost {
+				continue
+			}
+			scheme, rest, hasScheme := strings.Cut(s, "://")
+			if !hasScheme {
+				rest = s
+			}
+			hostPort, _, _ := strings.Cut(rest, "/")
+			_, port, _ := strings.Cut(hostPort, ":")
+
+			var msg string
+			switch {
+			case hasScheme && scheme == "*":
+				msg = "wildcard scheme allows the host over any scheme, including insecure ones"
+			case port == "*":
+				msg = "wildcard port allows every port of the host"
+			default:
+				continue
+			}
+			results = append(results, LintResult{Directive: directive, Source: s, Message: msg})
+		}
+	}
+	return results
+}
+
+// lintNoneConflict reports directives that combine 'none' with other
+// sources. Browsers ignore 'none' in that case, so the directive allows the
+// other sources rather than nothing.
+func lintNoneConflict(p *Policy) []LintResult {
+	var results []LintResult
+	for _, directive := range sortedKeys(p.directives) {
+		sources := p.directives[directive]
+		if _, ok := sources[SourceNone]; !ok || len(sources) == 1 {
+			continue
+		}
+		results = append(results, LintResult{
+			Directive: directive,
+			Source:    SourceNone,
+			Message:   "'none' is ignored when combined with other sources",
+		})
+	}
+	return results
+}
+
+// lintEmptyDirective reports directives without sources that are not
+// valueless. Add and Set never leave a directive in that state, but it can
+// arise when Blocklist strips the last source of a directive. Browsers treat
+// an empty source list as 'none'.
+func lintEmptyDirective(p *Policy) []LintResult {
+	var results []LintResult
+	for _, directive := range sortedKeys(p.directives) {
+		if len(p.directives[directive]) > 0 {
+			continue
+		}
+		if isValueless(directive) {
+			continue
+		}
+		results = append(results, LintResult{
+			Directive: directive,
+			Message:   "directive has no sources and is treated as 'none'; set 'none' explicitly if intended",
+		})
+	}
+	return results
+}
+
+// lintInlineStyleBlocked warns when the directive governing styles, style-src
+// or in its absence default-src, allows no inline styles: it has neither
+// 'unsafe-inline' nor a nonce or hash source. Many frameworks inject inline
+// styles, so such a policy often breaks pages unexpectedly.
+func lintInlineStyleBlocked(p *Policy) []LintResult {
+	for _, directive := range fallbackChain(StyleSrc) {
+		sources, ok := p.directives[directive]
+		if !ok {
+			continue
+		}
+		if _, inline := sources[SourceUnsafeInline]; inline || hasNonceOrHash(sources) {
+			return nil
+		}
+		return []LintResult{{
+			Directive: directive,
+			Message:   "inline styles are blocked; add 'unsafe-inline', a nonce, or hashes if the page uses them",
+		}}
+	}
+	return nil
+}
+
+// lintFrameAncestorsWildcard warns about frame-ancestors sources that let a
+// broad set of sites frame the page, which undermines its protection against
+// clickjacking: *, scheme sources, and hosts with a wildcard.
+func lintFrameAncestorsWildcard(p *Policy) []LintResult {
+	var results []LintResult
+	for _, s := range sortedKeys(p.directives[FrameAncestors]) {
+		var msg string
+		switch kind := ClassifySource(s); {
+		case s == "*":
+			msg = "any site may frame the page (clickjacking risk)"
+		case kind == KindScheme:
+			msg = "any site using the scheme may frame the page (clickjacking risk)"
+		case kind == KindHost && hostHasWildcard(s):
+			msg = "wildcard host lets every matching subdomain frame the page (clickjacking risk)"
+		default:
+			continue
+		}
+		results = append(results, LintResult{Directive: FrameAncestors, Source: s, Message: msg})
+	}
+	return results
+}
+
+// lintUpgradeInsecureHTTP warns about http: sources in a policy with
+// upgrade-insecure-requests. The browser rewrites those requests to https
+// before checking them, so allowing http: suggests a misunderstanding.
+func lintUpgradeInsecureHTTP(p *Policy) []LintResult {
+	if _, ok := p.directives[UpgradeInsecureRequests]; !ok {
+		return nil
+	}
+	var results []LintResult
+	for _, directive := range sortedKeys(p.directives) {
+		for _, s := range sortedKeys(p.directives[directive]) {
+			if strings.EqualFold(s, SchemeHTTP) {
+				results = append(results, LintResult{
+					Directive: directive,
+					Source:    s,
+					Message:   "http: is allowed but upgrade-insecure-requests rewrites such requests to https",
+				})
+			}
+		}
+	}
+	return results
+}
+
+// lintMissingBaseURI warns when scripts are allowed by nonce or
+// 'strict-dynamic', through script-src or, in its absence, default-src, but
+// base-uri is not set. An injected <base> element could then redirect
+// relative script URLs to an attacker's host, where a nonce-bearing or
+// trusted script would load them.
+func lintMissingBaseURI(p *Policy) []LintResult {
+	if _, ok := p.directives[BaseURI]; ok {
+		return nil
+	}
+	for _, directive := range fallbackChain(ScriptSrc) {
+		sources, ok := p.directives[directive]
+		if !ok {
+			continue
+		}
+		for s := range sources {
+			if s == SourceStrictDynamic || ClassifySource(s) == KindNonce {
+				return []LintResult{{
+					Directive: BaseURI,
+					Message:   "scripts rely on nonces or 'strict-dynamic' but base-uri is not set; add base-uri 'none' or 'self'",
+				}}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// ignoredUnsafeInlineDirectives lists the script directives checked by
+// lintIgnoredUnsafeInline. default-src is included because script-src may
+// fall back to it.
+var ignoredUnsafeInlineDirectives = []string{DefaultSrc, ScriptSrc, ScriptSrcElem}
+
+// lintIgnoredUnsafeInline reports 'unsafe-inline' in script directives that
+// also contain a nonce or hash, which makes browsers ignore it. Together with
+// 'strict-dynamic' this is the recommended fallback for browsers without
+// nonce support, so it is only noted; without 'strict-dynamic' it is more
+// likely an accident and the finding is raised to a warning.
+func lintIgnoredUnsafeInline(p *Policy) []LintResult {
+	var results []LintResult
+	for _, directive := range ignoredUnsafeInlineDirectives {
+		sources := p.directives[directive]
+		if _, inline := sources[SourceUnsafeInline]; !inline || !hasNonceOrHash(sources) {
+			continue
+		}
+		r := LintResult{
+			Directive: directive,
+			Source:    SourceUnsafeInline,
+			Message:   "'unsafe-inline' is ignored by browsers that support nonces and hashes; kept as a fallback for older browsers",
+		}
+		if _, ok := sources[SourceStrictDynamic]; !ok {
+			r.Severity = SeverityWarning
+			r.Message = "'unsafe-inline' is ignored because a nonce or hash is present; remove it or add 'strict-dynamic'"
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// hostHasWildcard reports whether the host part of a host source starts with
+// a wildcard.
+func hostHasWildcard(s string) bool {
+	if _, rest, ok := strings.Cut(s, "://"); ok {
+		s = rest
+	}
+	return strings.HasPrefix(s, "*")
+}
+
+// metaUnsupportedDirectives lists the directives that browsers ignore in a
+// policy delivered in a <meta> element.
+var metaUnsupportedDirectives = []string{FrameAncestors, ReportTo, ReportURI, Sandbox}
+
+// lintMetaUnsupported warns about directives that have no effect in a <meta>
+// element.
+func lintMetaUnsupported(p *Policy) []LintResult {
+	var results []LintResult
+	for _, directive := range metaUnsupportedDirectives {
+		if _, ok := p.directives[directive]; !ok {
+			continue
+		}
+		results = append(results, LintResult{
+			Directive: directive,
+			Message:   "directive is ignored when the policy is delivered in a <meta> element",
+		})
+	}
+	return results
+}
+
+// noteUnsafe records a finding about dropped input, to be reported by Lint.
+// It assumes the caller holds the mutex.
+func (p *Policy) noteUnsafe(directive, source, message string) {
+	p.notes = append(p.notes, LintResult{Directive: directive, Source: source, Message: message})
+}
+
+// noteOnceUnsafe is like noteUnsafe but does not record a finding that is
+// already present. It assumes the caller holds the mutex.
+func (p *Policy) noteOnceUnsafe(directive, source, message string) {
+	note := LintResult{Directive: directive, Source: source, Message: message}
+	if !slices.Contains(p.notes, note) {
+		p.notes = append(p.notes, note)
+	}
+}
+
+// clearNotesUnsafe discards the recorded findings for a directive.
+// It assumes the caller holds the mutex.
+func (p *Policy) clearNotesUnsafe(directive string) {
+	p.notes = slices.DeleteFunc(p.notes, func(r LintResult) bool { return r.Directive == directive })
+}
+
+// lintNotes reports the findings recorded when input was dropped.
+func lintNotes(p *Policy) []LintResult {
+	return slices.Clone(p.notes)
+}
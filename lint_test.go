@@ -0,0 +1,758 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// hasLintMessage reports whether results contain a finding for the directive
+// whose message contains substr.
+func hasLintMessage(results []LintResult, directive, substr string) bool {
+	for _, r := range results {
+		if r.Directive == directive && strings.Contains(r.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestPolicy_Lint_MissingDefaultSrc verifies that Lint warns when default-src
+// is absent and stays silent when it is present.
+func TestPolicy_Lint_MissingDefaultSrc(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		wantWarn bool
+	}{
+		{
+			name:     "only script-src",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, SourceSelf) },
+			wantWarn: true,
+		},
+		{
+			name: "default-src present",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.Add(ScriptSrc, SourceSelf)
+			},
+			wantWarn: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			got := hasLintMessage(p.Lint(), "", "no default-src set")
+			if got != tt.wantWarn {
+				t.Errorf("missing default-src warning = %v, want %v", got, tt.wantWarn)
+			}
+			if p.HasDefaultSrc() == tt.wantWarn {
+				t.Errorf("HasDefaultSrc() = %v, want %v", p.HasDefaultSrc(), !tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestLintResult_String verifies the formatting of lint findings.
+func TestLintResult_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		result LintResult
+		want   string
+	}{
+		{LintResult{Message: "policy-wide"}, "policy-wide"},
+		{LintResult{Directive: ScriptSrc, Message: "scoped"}, "script-src: scoped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.result.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_ReportSample verifies that 'report-sample' is flagged only
+// outside script and style directives.
+func TestPolicy_Lint_ReportSample(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		directive string
+		wantWarn  bool
+	}{
+		{"img-src", ImgSrc, true},
+		{"connect-src", ConnectSrc, true},
+		{"script-src", ScriptSrc, false},
+		{"style-src-elem", StyleSrcElem, false},
+		{"default-src", DefaultSrc, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(tt.directive, SourceSelf, SourceReportSample)
+			got := hasLintMessage(p.Lint(), tt.directive, "'report-sample'")
+			if got != tt.wantWarn {
+				t.Errorf("report-sample warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_TrailingSlash verifies that host sources differing only by
+// a trailing slash are flagged, while different paths are not.
+func TestPolicy_Lint_TrailingSlash(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sources  []string
+		wantWarn bool
+	}{
+		{"host with and without slash", []string{"https://example.com", "https://example.com/"}, true},
+		{"path with and without slash", []string{"https://example.com/js", "https://example.com/js/"}, true},
+		{"different paths", []string{"https://example.com/a/", "https://example.com/b"}, false},
+		{"single host with slash", []string{"https://example.com/"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ScriptSrc, tt.sources...)
+			got := hasLintMessage(p.Lint(), ScriptSrc, "match different paths")
+			if got != tt.wantWarn {
+				t.Errorf("trailing slash warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_ReportEndpointBlocked verifies that a report-uri endpoint
+// not allowed by connect-src is flagged.
+func TestPolicy_Lint_ReportEndpointBlocked(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		wantWarn bool
+	}{
+		{
+			name: "endpoint not in connect-src",
+			setup: func(p *Policy) {
+				p.Add(ConnectSrc, SourceSelf)
+				p.Add(ReportURI, "https://reports.example.com/csp")
+			},
+			wantWarn: true,
+		},
+		{
+			name: "endpoint not in default-src fallback",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, "https://app.example.com")
+				p.Add(ReportURI, "https://reports.example.com/csp")
+			},
+			wantWarn: true,
+		},
+		{
+			name: "endpoint in connect-src",
+			setup: func(p *Policy) {
+				p.Add(ConnectSrc, "https://reports.example.com")
+				p.Add(ReportURI, "https://reports.example.com/csp")
+			},
+			wantWarn: false,
+		},
+		{
+			name: "relative endpoint",
+			setup: func(p *Policy) {
+				p.Add(ConnectSrc, SourceNone)
+				p.Add(ReportURI, "/csp-report")
+			},
+			wantWarn: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			got := hasLintMessage(p.Lint(), ReportURI, "reports may be blocked")
+			if got != tt.wantWarn {
+				t.Errorf("blocked report endpoint warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_MissingFrameAncestors verifies the frame-ancestors
+// suggestion and that it can be disabled.
+func TestPolicy_Lint_MissingFrameAncestors(t *testing.T) {
+	t.Parallel()
+
+	const msg = "consider frame-ancestors"
+
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		wantWarn bool
+	}{
+		{
+			name:     "missing frame-ancestors",
+			setup:    func(p *Policy) { p.Add(DefaultSrc, SourceSelf) },
+			wantWarn: true,
+		},
+		{
+			name: "frame-ancestors present",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.Add(FrameAncestors, SourceNone)
+			},
+			wantWarn: false,
+		},
+		{
+			name:     "script-src only",
+			setup:    func(p *Policy) { p.Add(ScriptSrc, SourceSelf) },
+			wantWarn: true,
+		},
+		{
+			name:     "object-src only",
+			setup:    func(p *Policy) { p.Add(ObjectSrc, SourceNone) },
+			wantWarn: true,
+		},
+		{
+			name:     "no security directives",
+			setup:    func(p *Policy) { p.Add(ImgSrc, SourceSelf); p.Add(UpgradeInsecureRequests) },
+			wantWarn: false,
+		},
+		{
+			name:     "empty policy",
+			setup:    func(p *Policy) {},
+			wantWarn: false,
+		},
+		{
+			name: "check disabled",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.DisableLintChecks(CheckMissingFrameAncestors)
+			},
+			wantWarn: false,
+		},
+		{
+			name: "check re-enabled",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.DisableLintChecks(CheckMissingFrameAncestors)
+				p.EnableLintChecks(CheckMissingFrameAncestors)
+			},
+			wantWarn: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			got := hasLintMessage(p.Lint(), "", msg)
+			if got != tt.wantWarn {
+				t.Errorf("frame-ancestors warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_Check verifies that each finding records the check that
+// produced it.
+func TestPolicy_Lint_Check(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf)
+
+	for _, r := range p.Lint() {
+		if r.Check == "" {
+			t.Errorf("finding %q has no check", r)
+		}
+	}
+	p.DisableLintChecks(CheckMissingDefaultSrc, CheckMissingFrameAncestors)
+	if got := p.Lint(); got != nil {
+		t.Errorf("Lint() with checks disabled = %v, want nil", got)
+	}
+}
+
+// TestPolicy_LintForContext verifies that directives unsupported in a <meta>
+// element are reported only in the meta context.
+func TestPolicy_LintForContext(t *testing.T) {
+	t.Parallel()
+
+	const msg = "<meta> element"
+	tests := []struct {
+		name      string
+		directive string
+		ctx       Context
+		wantWarn  bool
+	}{
+		{"frame-ancestors in header", FrameAncestors, ContextHeader, false},
+		{"frame-ancestors in meta", FrameAncestors, ContextMeta, true},
+		{"sandbox in meta", Sandbox, ContextMeta, true},
+		{"report-uri in meta", ReportURI, ContextMeta, true},
+		{"report-to in meta", ReportTo, ContextMeta, true},
+		{"script-src in meta", ScriptSrc, ContextMeta, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(DefaultSrc, SourceSelf)
+			p.Add(tt.directive, "https://a.com")
+			got := hasLintMessage(p.LintForContext(tt.ctx), tt.directive, msg)
+			if got != tt.wantWarn {
+				t.Errorf("meta warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_WildcardPortOrScheme verifies that host sources with a
+// wildcard port or scheme are flagged.
+func TestPolicy_Lint_WildcardPortOrScheme(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source   string
+		wantWarn string
+	}{
+		{"https://example.com:*", "wildcard port"},
+		{"example.com:*/path", "wildcard port"},
+		{"*://example.com", "wildcard scheme"},
+		{"https://example.com", ""},
+		{"https://*.example.com:443", ""},
+		{"*", ""},
+		{SchemeHTTPS, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ImgSrc, tt.source)
+			p.DisableLintChecks(CheckMissingDefaultSrc)
+			results := p.Lint()
+			if tt.wantWarn == "" {
+				if results != nil {
+					t.Errorf("Lint() = %v, want nil", results)
+				}
+				return
+			}
+			if !hasLintMessage(results, ImgSrc, tt.wantWarn) {
+				t.Errorf("Lint() = %v, want a finding containing %q", results, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestFilterBySeverity verifies that filtering keeps only findings at or
+// above the threshold, in order.
+func TestFilterBySeverity(t *testing.T) {
+	t.Parallel()
+
+	results := []LintResult{
+		{Severity: SeverityInfo, Message: "info"},
+		{Severity: SeverityError, Message: "error"},
+		{Severity: SeverityWarning, Message: "warning"},
+	}
+
+	tests := []struct {
+		threshold Severity
+		want      []string
+	}{
+		{SeverityInfo, []string{"info", "error", "warning"}},
+		{SeverityWarning, []string{"error", "warning"}},
+		{SeverityError, []string{"error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.threshold.String(), func(t *testing.T) {
+			t.Parallel()
+			var got []string
+			for _, r := range FilterBySeverity(results, tt.threshold) {
+				got = append(got, r.Message)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FilterBySeverity(%v) = %v, want %v", tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_Severity verifies the severity assigned to findings,
+// including the error-level 'none' conflict.
+func TestPolicy_Lint_Severity(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ObjectSrc, SourceNone, SourceSelf)
+	p.Add(ImgSrc, "https://example.com:*")
+
+	results := p.Lint()
+	errs := FilterBySeverity(results, SeverityError)
+	if len(errs) != 1 || errs[0].Check != CheckNoneConflict || errs[0].Directive != ObjectSrc {
+		t.Errorf("error findings = %v, want a single none-conflict for object-src", errs)
+	}
+	for _, r := range results {
+		if r.Check == CheckWildcardPortOrScheme && r.Severity != SeverityInfo {
+			t.Errorf("wildcard port severity = %v, want %v", r.Severity, SeverityInfo)
+		}
+		if r.Check == CheckMissingDefaultSrc && r.Severity != SeverityWarning {
+			t.Errorf("missing default-src severity = %v, want %v", r.Severity, SeverityWarning)
+		}
+	}
+
+	p.Set(ObjectSrc, SourceNone)
+	if errs := FilterBySeverity(p.Lint(), SeverityError); errs != nil {
+		t.Errorf("error findings = %v, want nil", errs)
+	}
+}
+
+// TestPolicy_Lint_EmptyDirective verifies that directives left without
+// sources are reported unless they are valueless.
+func TestPolicy_Lint_EmptyDirective(t *testing.T) {
+	t.Parallel()
+
+	const msg = "no sources"
+	tests := []struct {
+		name      string
+		setup     func(*Policy)
+		directive string
+		wantNote  bool
+	}{
+		{
+			name: "last source blocklisted",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceUnsafeInline)
+				p.Blocklist(SourceUnsafeInline)
+			},
+			directive: ScriptSrc,
+			wantNote:  true,
+		},
+		{
+			name:      "empty source set",
+			setup:     func(p *Policy) { p.directives[ImgSrc] = map[string]struct{}{} },
+			directive: ImgSrc,
+			wantNote:  true,
+		},
+		{
+			name:      "valueless directive",
+			setup:     func(p *Policy) { p.Add(UpgradeInsecureRequests) },
+			directive: UpgradeInsecureRequests,
+			wantNote:  false,
+		},
+		{
+			name:      "directive with sources",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, SourceSelf) },
+			directive: ScriptSrc,
+			wantNote:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			if got := hasLintMessage(p.Lint(), tt.directive, msg); got != tt.wantNote {
+				t.Errorf("empty directive note = %v, want %v", got, tt.wantNote)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_InlineStyleBlocked verifies that a style policy without any
+// inline allowance is flagged, following the default-src fallback.
+func TestPolicy_Lint_InlineStyleBlocked(t *testing.T) {
+	t.Parallel()
+
+	const msg = "inline styles are blocked"
+	tests := []struct {
+		name      string
+		setup     func(*Policy)
+		directive string
+		wantWarn  bool
+	}{
+		{"style-src self", func(p *Policy) { p.Add(StyleSrc, SourceSelf) }, StyleSrc, true},
+		{"style-src unsafe-inline", func(p *Policy) { p.Add(StyleSrc, SourceSelf, SourceUnsafeInline) }, StyleSrc, false},
+		{"style-src nonce", func(p *Policy) { p.Add(StyleSrc, SourceNonce) }, StyleSrc, false},
+		{"style-src hash", func(p *Policy) { p.Add(StyleSrc, "'sha256-eHl6'") }, StyleSrc, false},
+		{"default-src fallback", func(p *Policy) { p.Add(DefaultSrc, SourceSelf) }, DefaultSrc, true},
+		{
+			name: "style-src overrides default-src",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceNone)
+				p.Add(StyleSrc, SourceUnsafeInline)
+			},
+			directive: DefaultSrc,
+			wantWarn:  false,
+		},
+		{"no restriction", func(p *Policy) { p.Add(ImgSrc, SourceSelf) }, ImgSrc, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			if got := hasLintMessage(p.Lint(), tt.directive, msg); got != tt.wantWarn {
+				t.Errorf("inline style warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_FrameAncestorsWildcard verifies that broad frame-ancestors
+// sources warn while exact hosts do not.
+func TestPolicy_Lint_FrameAncestorsWildcard(t *testing.T) {
+	t.Parallel()
+
+	const msg = "clickjacking risk"
+	tests := []struct {
+		name     string
+		sources  []string
+		wantWarn bool
+	}{
+		{"exact hosts", []string{SourceSelf, "https://partner.com", "app.partner.com"}, false},
+		{"wildcard host", []string{SourceSelf, "https://*.partner.com"}, true},
+		{"wildcard host without scheme", []string{"*.partner.com"}, true},
+		{"star", []string{"*"}, true},
+		{"scheme", []string{SchemeHTTPS}, true},
+		{"none", []string{SourceNone}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(FrameAncestors, tt.sources...)
+			if got := hasLintMessage(p.Lint(), FrameAncestors, msg); got != tt.wantWarn {
+				t.Errorf("frame-ancestors wildcard warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+
+	p := New()
+	p.Add(ImgSrc, "https://*.partner.com")
+	if hasLintMessage(p.Lint(), ImgSrc, msg) {
+		t.Error("wildcard outside frame-ancestors reported as a clickjacking risk")
+	}
+}
+
+// TestPolicy_Lint_UpgradeInsecureHTTP verifies that http: sources warn only
+// alongside upgrade-insecure-requests.
+func TestPolicy_Lint_UpgradeInsecureHTTP(t *testing.T) {
+	t.Parallel()
+
+	const msg = "upgrade-insecure-requests rewrites"
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		wantWarn bool
+	}{
+		{
+			name: "both",
+			setup: func(p *Policy) {
+				p.Add(UpgradeInsecureRequests)
+				p.Add(ImgSrc, SourceSelf, SchemeHTTP)
+			},
+			wantWarn: true,
+		},
+		{
+			name:     "http without upgrade",
+			setup:    func(p *Policy) { p.Add(ImgSrc, SourceSelf, SchemeHTTP) },
+			wantWarn: false,
+		},
+		{
+			name: "upgrade without http",
+			setup: func(p *Policy) {
+				p.Add(UpgradeInsecureRequests)
+				p.Add(ImgSrc, SourceSelf, SchemeHTTPS)
+			},
+			wantWarn: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			if got := hasLintMessage(p.Lint(), ImgSrc, msg); got != tt.wantWarn {
+				t.Errorf("upgrade-insecure-requests warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_MissingBaseURI verifies that nonce and 'strict-dynamic'
+// based script policies warn when base-uri is unset.
+func TestPolicy_Lint_MissingBaseURI(t *testing.T) {
+	t.Parallel()
+
+	const msg = "base-uri is not set"
+	tests := []struct {
+		name     string
+		setup    func(*Policy)
+		wantWarn bool
+	}{
+		{"nonce", func(p *Policy) { p.Add(ScriptSrc, SourceNonce) }, true},
+		{"strict-dynamic", func(p *Policy) { p.Add(ScriptSrc, "'sha256-eHl6'", SourceStrictDynamic) }, true},
+		{"nonce in default-src", func(p *Policy) { p.Add(DefaultSrc, SourceSelf, SourceNonce) }, true},
+		{
+			name: "base-uri set",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceNonce)
+				p.Add(BaseURI, SourceNone)
+			},
+			wantWarn: false,
+		},
+		{"host-based script-src", func(p *Policy) { p.Add(ScriptSrc, SourceSelf) }, false},
+		{
+			name: "script-src overrides default-src",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceNonce)
+				p.Add(ScriptSrc, SourceSelf)
+			},
+			wantWarn: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			if got := hasLintMessage(p.Lint(), BaseURI, msg); got != tt.wantWarn {
+				t.Errorf("missing base-uri warning = %v, want %v", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_SelfReportEndpoint verifies that a report endpoint on the
+// document origin is noted.
+func TestPolicy_Lint_SelfReportEndpoint(t *testing.T) {
+	t.Parallel()
+
+	const msg = "report endpoint is on the document origin"
+	tests := []struct {
+		name     string
+		origin   string
+		endpoint string
+		wantNote bool
+	}{
+		{"matching origin", "https://example.com", "https://example.com/csp-report", true},
+		{"default port", "https://example.com", "https://EXAMPLE.com:443/csp-report", true},
+		{"other origin", "https://example.com", "https://reports.example.com/csp", false},
+		{"other scheme", "https://example.com", "http://example.com/csp", false},
+		{"relative endpoint", "https://example.com", "/csp-report", false},
+		{"no origin", "", "https://example.com/csp-report", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(DefaultSrc, SourceSelf)
+			p.Add(ReportURI, tt.endpoint)
+			if tt.origin != "" {
+				p = p.WithOrigin(tt.origin)
+			}
+			if got := hasLintMessage(p.Lint(), ReportURI, msg); got != tt.wantNote {
+				t.Errorf("self report endpoint note = %v, want %v", got, tt.wantNote)
+			}
+		})
+	}
+}
+
+// TestPolicy_Lint_IgnoredUnsafeInline verifies that 'unsafe-inline' next to a
+// nonce is noted with 'strict-dynamic' and a warning without it.
+func TestPolicy_Lint_IgnoredUnsafeInline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		setup        func(*Policy)
+		directive    string
+		wantFound    bool
+		wantSeverity Severity
+	}{
+		{
+			name:         "strict-dynamic recipe",
+			setup:        func(p *Policy) { p.Add(ScriptSrc, SourceNonce, SourceStrictDynamic, SourceUnsafeInline) },
+			directive:    ScriptSrc,
+			wantFound:    true,
+			wantSeverity: SeverityInfo,
+		},
+		{
+			name:         "without strict-dynamic",
+			setup:        func(p *Policy) { p.Add(ScriptSrc, SourceSelf, SourceNonce, SourceUnsafeInline) },
+			directive:    ScriptSrc,
+			wantFound:    true,
+			wantSeverity: SeverityWarning,
+		},
+		{
+			name:         "hash in script-src-elem",
+			setup:        func(p *Policy) { p.Add(ScriptSrcElem, "'sha256-eHl6'", SourceUnsafeInline) },
+			directive:    ScriptSrcElem,
+			wantFound:    true,
+			wantSeverity: SeverityWarning,
+		},
+		{
+			name:      "unsafe-inline only",
+			setup:     func(p *Policy) { p.Add(ScriptSrc, SourceSelf, SourceUnsafeInline) },
+			directive: ScriptSrc,
+		},
+		{
+			name:      "style-src",
+			setup:     func(p *Policy) { p.Add(StyleSrc, SourceNonce, SourceUnsafeInline) },
+			directive: StyleSrc,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			var found []LintResult
+			for _, r := range p.Lint() {
+				if r.Check == CheckIgnoredUnsafeInline {
+					found = append(found, r)
+				}
+			}
+			if !tt.wantFound {
+				if len(found) != 0 {
+					t.Errorf("Lint() = %v, want no ignored-unsafe-inline finding", found)
+				}
+				return
+			}
+			if len(found) != 1 || found[0].Directive != tt.directive {
+				t.Fatalf("Lint() = %v, want one ignored-unsafe-inline finding for %s", found, tt.directive)
+			}
+			if found[0].Severity != tt.wantSeverity {
+				t.Errorf("\nexpected: %v\ngot:      %v", tt.wantSeverity, found[0].Severity)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+)
+
+// Merge adds all directives and sources of other to the policy. Sources of
+// directives present in both policies are combined by union, and valueless
+// directives present only in other are carried over. other is not modified.
+func (p *Policy) Merge(other *Policy) {
+	p.checkSealed()
+
+	if other == nil || other == p {
+		return
+	}
+
+	// Copy other first so that both locks are never held at the same time.
+	incoming := other.normalizedDirectives(identitySource)
+	if len(incoming) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for directive, sources := range incoming {
+		if len(sources) > 0 {
+			p.addSourcesUnsafe(directive, sortedKeys(sources))
+		} else if _, ok := p.directives[directive]; !ok {
+			p.directives[directive] = make(map[string]struct{})
+		}
+	}
+	p.invalidateCache()
+}
+
+// MergeAll returns a new policy that is the union of the given policies,
+// merged from left to right as by Merge, for layered configuration such as
+// global, environment, service and route policies. Nil policies are skipped,
+// and none of the inputs is modified. Only directives and their sources are
+// merged; settings such as blocklists, source limits and origins are not
+// carried over.
+func MergeAll(policies ...*Policy) *Policy {
+	result := New()
+	for _, p := range policies {
+		result.Merge(p)
+	}
+	return result
+}
+
+// SetAll replaces the entire policy with the given directives in a single
+// locked operation, so concurrent callers of Compile observe either the old
+// or the new policy, never a mix. Directives not present in the map are
+// removed. Each entry is normalized as by Set: directive names are lowercased,
+// empty sources are ignored, and entries left without sources are dropped
+// unless the directive is valueless. Entries whose names normalize to the same
+// directive are combined.
+func (p *Policy) SetAll(directives map[string][]string) {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.directives = make(map[string]map[string]struct{}, len(directives))
+	p.capped = nil
+	p.displayNames = nil
+	p.provenance = nil
+	// Every directive is replaced, so notes about dropped sources are stale;
+	// policy-wide notes, which have no directive, are kept.
+	p.notes = slices.DeleteFunc(p.notes, func(r LintResult) bool { return r.Directive != "" })
+	for directive, sources := range directives {
+		key := normalizeDirective(directive)
+		if key == "" {
+			continue
+		}
+		set, ok := p.directives[key]
+		if !ok {
+			set = make(map[string]struct{}, len(sources))
+		}
+		for _, source := range sources {
+			if s := strings.TrimSpace(source); s != "" {
+				p.addSourceUnsafe(key, set, s)
+			}
+		}
+		if len(set) > 0 || isValueless(key) {
+			p.directives[key] = set
+		}
+	}
+	for key := range p.reportOnly {
+		if _, ok := p.directives[key]; !ok {
+			delete(p.reportOnly, key)
+		}
+	}
+	p.pruneHashGroupsUnsafe()
+	p.invalidateCache()
+}
+
+// AddSet adds the sources of every entry in directives as if by Add, so that
+// a whole policy can be declared as a map literal. Unlike SetAll, it merges by
+// union: directives already in the policy keep their sources, and directives
+// not mentioned are unchanged. Entries are applied in alphabetical order of
+// their keys, which keeps the result deterministic when a source limit is set.
+func (p *Policy) AddSet(directives map[string][]string) {
+	for _, directive := range sortedKeys(directives) {
+		p.Add(directive, directives[directive]...)
+	}
+}
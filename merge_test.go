@@ -0,0 +1,242 @@
+package csp
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestPolicy_Merge verifies that Merge unions sources, carries over new
+// directives, and leaves the other policy untouched.
+func TestPolicy_Merge(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceSelf)
+	p.Compile() // Prime the cache to verify invalidation
+
+	other := New()
+	other.Add(ScriptSrc, "https://cdn.example.com")
+	other.Add(ImgSrc, SchemeData)
+
+	p.Merge(other)
+
+	expected := "default-src 'self'; img-src data:; script-src 'self' https://cdn.example.com"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+
+	// Changes to the merged policy must not leak into other.
+	p.Add(ImgSrc, SchemeBlob)
+	expectedOther := "img-src data:; script-src https://cdn.example.com"
+	if got := other.Compile(); got != expectedOther {
+		t.Errorf("\nexpected: %s\ngot:      %s", expectedOther, got)
+	}
+
+	// Merging nil or itself is a no-op.
+	p.Merge(nil)
+	p.Merge(p)
+}
+
+// TestPolicy_Merge_Valueless verifies that valueless directives survive the
+// union, both when they are new and when both policies set them.
+func TestPolicy_Merge_Valueless(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(Sandbox)
+	p.Compile() // Prime the cache to verify invalidation
+
+	other := New()
+	other.Add(UpgradeInsecureRequests)
+	other.Add(Sandbox)
+
+	p.Merge(other)
+
+	expected := "default-src 'self'; sandbox; upgrade-insecure-requests"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
+
+// TestPolicy_SetAll verifies that SetAll replaces all directives with
+// normalized entries.
+func TestPolicy_SetAll(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ImgSrc, SchemeData)
+	p.Compile() // Prime the cache to verify invalidation
+
+	p.SetAll(map[string][]string{
+		" Default-Src ":         {SourceSelf, " "},
+		ScriptSrc:               {"https://cdn.example.com"},
+		StyleSrc:                {"", "  "},
+		UpgradeInsecureRequests: nil,
+	})
+
+	expected := "default-src 'self'; script-src https://cdn.example.com; upgrade-insecure-requests"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
+
+// TestPolicy_SetAll_StaleState verifies that SetAll forgets the lint notes
+// and hash groups of the directives it replaces, while keeping notes about
+// its own input.
+func TestPolicy_SetAll_StaleState(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Blocklist("https://evil.example.com")
+	p.AddScheme(ImgSrc, "https")
+	p.Add(StyleSrc, "https://evil.example.com")
+	if err := p.AddInlineScripts([]string{"sha256", "sha384"}, "alert(1)"); err != nil {
+		t.Fatalf("AddInlineScripts() error = %v", err)
+	}
+
+	p.SetAll(map[string][]string{
+		DefaultSrc: {SourceSelf},
+		FontSrc:    {"https://evil.example.com"},
+	})
+
+	results := p.Lint()
+	for _, directive := range []string{ImgSrc, StyleSrc} {
+		if hasLintMessage(results, directive, "") {
+			t.Errorf("Lint() = %v, want no findings for %s", results, directive)
+		}
+	}
+	if !hasLintMessage(results, FontSrc, "blocklisted") {
+		t.Errorf("Lint() = %v, want the blocklist finding for %s", results, FontSrc)
+	}
+	if len(p.hashGroups) != 0 {
+		t.Errorf("hashGroups = %v, want none", p.hashGroups)
+	}
+}
+
+// TestPolicy_SetAll_Atomic verifies that concurrent readers never observe a
+// partially applied configuration.
+func TestPolicy_SetAll_Atomic(t *testing.T) {
+	t.Parallel()
+
+	configA := map[string][]string{
+		DefaultSrc: {SourceSelf},
+		ScriptSrc:  {SourceSelf},
+		ImgSrc:     {SchemeData},
+	}
+	configB := map[string][]string{
+		DefaultSrc: {SourceNone},
+		StyleSrc:   {"https://b.example.com"},
+		FontSrc:    {"https://b.example.com"},
+	}
+
+	want := make(map[string]bool, 2)
+	for _, config := range []map[string][]string{configA, configB} {
+		ref := New()
+		ref.SetAll(config)
+		want[ref.Compile()] = true
+	}
+
+	p := New()
+	p.SetAll(configA)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 500 {
+			if i%2 == 0 {
+				p.SetAll(configB)
+			} else {
+				p.SetAll(configA)
+			}
+		}
+		close(done)
+	}()
+
+	const readers = 4
+	wg.Add(readers)
+	for range readers {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if got := p.Compile(); !want[got] {
+					t.Errorf("Compile() observed partial configuration %q", got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestPolicy_AddSet verifies that AddSet normalizes its entries and unions
+// them with existing directives.
+func TestPolicy_AddSet(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf)
+	p.Add(ImgSrc, SchemeData)
+	p.Compile() // Prime the cache to verify invalidation
+
+	p.AddSet(map[string][]string{
+		" Script-Src ":          {"https://cdn.example.com", " "},
+		DefaultSrc:              {SourceSelf},
+		StyleSrc:                {""},
+		UpgradeInsecureRequests: nil,
+	})
+
+	expected := "default-src 'self'; img-src data:; script-src 'self' https://cdn.example.com; upgrade-insecure-requests"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
+
+// TestMergeAll verifies that MergeAll unions layered policies into a new
+// policy without modifying any of them.
+func TestMergeAll(t *testing.T) {
+	t.Parallel()
+
+	global := New()
+	global.Add(DefaultSrc, SourceSelf)
+	env := New()
+	env.Add(ConnectSrc, "https://api.staging.example.com")
+	env.Add(DefaultSrc, "https://cdn.example.com")
+	route := New()
+	route.Add(UpgradeInsecureRequests)
+
+	merged := MergeAll(global, nil, env, route)
+
+	expected := "connect-src https://api.staging.example.com; default-src 'self' https://cdn.example.com; " +
+		"upgrade-insecure-requests"
+	if got := merged.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+
+	merged.Add(ImgSrc, SourceSelf)
+	for name, tc := range map[string]struct {
+		p        *Policy
+		expected string
+	}{
+		"global": {global, "default-src 'self'"},
+		"env":    {env, "connect-src https://api.staging.example.com; default-src https://cdn.example.com"},
+		"route":  {route, "upgrade-insecure-requests"},
+	} {
+		if got := tc.p.Compile(); got != tc.expected {
+			t.Errorf("%s policy changed:\nexpected: %s\ngot:      %s", name, tc.expected, got)
+		}
+	}
+
+	if got := MergeAll().Compile(); got != "" {
+		t.Errorf("MergeAll() = %q, want empty policy", got)
+	}
+}
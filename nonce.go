@@ -0,0 +1,218 @@
+package csp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// namedNoncePrefix starts a named nonce placeholder, which is completed by the
+// nonce name and a closing "}}".
+const namedNoncePrefix = "{{nonce:"
+
+// nonceContextKey is the context key under which WithNonce stores a nonce.
+type nonceContextKey struct{}
+
+// WithNonce returns a copy of ctx carrying the given per-request nonce, so
+// that handlers and templates further down the chain can retrieve it with
+// NonceFromContext or NonceAttrFromContext.
+func WithNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, nonceContextKey{}, nonce)
+}
+
+// NonceFromContext returns the nonce stored in ctx by WithNonce, and whether
+// one was present.
+func NonceFromContext(ctx context.Context) (string, bool) {
+	nonce, ok := ctx.Value(nonceContextKey{}).(string)
+	return nonce, ok
+}
+
+// nonceBytes is the number of random bytes in a nonce generated by newNonce.
+const nonceBytes = 16
+
+// newNonce returns a nonce of nonceBytes random bytes from crypto/rand,
+// encoded as standard base64.
+func newNonce() (string, error) {
+	b := make([]byte, nonceBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("read random bytes: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// ValidateNonce checks that a nonce, given raw or in its quoted source form
+// ('nonce-...'), is valid base64 in the standard or URL-safe alphabet, with or
+// without padding, and decodes to at least 16 bytes, the length produced by
+// CompileWithFreshNonce. Middleware can use it to reject weak nonces supplied
+// by an upstream component.
+func ValidateNonce(nonce string) error {
+	value := bareNonce(nonce)
+	if value == "" {
+		return errors.New("nonce is empty")
+	}
+
+	var decoded []byte
+	var err error
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding,
+	} {
+		if decoded, err = enc.DecodeString(value); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("nonce %q is not valid base64", value)
+	}
+	if len(decoded) < nonceBytes {
+		return fmt.Errorf("nonce decodes to %d bytes, want at least %d", len(decoded), nonceBytes)
+	}
+	return nil
+}
+
+// NonceAttr returns the HTML attribute nonce="<value>" for use in templates,
+// e.g. <script {{.NonceAttr}}>. The nonce may be given raw or in its quoted
+// source form ('nonce-...'); the value is HTML-escaped.
+func NonceAttr(nonce string) template.HTMLAttr {
+	//nolint:gosec // G203: the only dynamic part is escaped with HTMLEscapeString.
+	return template.HTMLAttr(`nonce="` + template.HTMLEscapeString(bareNonce(nonce)) + `"`)
+}
+
+// NonceAttrFromContext returns NonceAttr for the nonce stored in ctx by
+// WithNonce, or an empty attribute if there is none.
+func NonceAttrFromContext(ctx context.Context) template.HTMLAttr {
+	nonce, ok := NonceFromContext(ctx)
+	if !ok || bareNonce(nonce) == "" {
+		return ""
+	}
+	return NonceAttr(nonce)
+}
+
+// WithoutNonce returns a clone of the policy with every nonce placeholder,
+// shared or named, removed. Directives left without sources are removed as
+// well, unless they are valueless directives. This is useful for serving a nonce-free variant
+// of a base policy on cacheable responses. The original policy is unchanged.
+func (p *Policy) WithoutNonce() *Policy {
+	cloned := p.Clone()
+
+	changed := false
+	for directive, sources := range cloned.directives {
+		n := len(sources)
+		for s := range sources {
+			if isNoncePlaceholder(s) {
+				delete(sources, s)
+			}
+		}
+		if len(sources) == n {
+			continue
+		}
+		changed = true
+		if len(sources) == 0 && !isValueless(directive) {
+			cloned.removeUnsafe(directive)
+		}
+	}
+	if changed {
+		cloned.invalidateCache()
+	}
+	return cloned
+}
+
+// NamedNonce returns the placeholder for the nonce called name, such as
+// "{{nonce:scripts}}". Unlike SourceNonce, which stands for the single nonce
+// of a response, named placeholders let one policy carry several nonces that
+// are supplied separately through CompileNamed. Compile and the other compile
+// methods substitute their one nonce for every placeholder, named or not.
+func NamedNonce(name string) string {
+	return namedNoncePrefix + strings.TrimSpace(name) + "}}"
+}
+
+// isNoncePlaceholder reports whether s is SourceNonce or a named nonce
+// placeholder.
+func isNoncePlaceholder(s string) bool {
+	if s == noncePlaceholder {
+		return true
+	}
+	name, ok := strings.CutPrefix(s, namedNoncePrefix)
+	return ok && len(name) > 2 && strings.HasSuffix(name, "}}")
+}
+
+// CompileNamed generates the CSP header string like Compile, substituting the
+// nonce nonces[name] for each placeholder created by NamedNonce(name). The
+// shared SourceNonce placeholder takes nonces[""]. A placeholder without a
+// usable nonce is kept wrapped as a nonce source, as Compile does without a
+// nonce.
+func (p *Policy) CompileNamed(nonces map[string]string) string {
+	cache, _, needsNonce := p.compiledCache()
+	if !needsNonce {
+		return cache
+	}
+	return replaceNoncePlaceholders(cache, func(name string) string { return nonces[name] })
+}
+
+// UseSeparateNonces replaces the shared SourceNonce placeholder in every
+// directive that has one with a placeholder named after the directive, so
+// that each injection point can be given its own nonce through CompileNamed.
+// For example, script-src and style-src get NamedNonce("script-src") and
+// NamedNonce("style-src"), supplied as nonces["script-src"] and
+// nonces["style-src"]. Directives without the shared placeholder are
+// unchanged.
+func (p *Policy) UseSeparateNonces() {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	for key, sources := range p.directives {
+		if _, ok := sources[SourceNonce]; !ok {
+			continue
+		}
+		named := NamedNonce(key)
+		delete(sources, SourceNonce)
+		sources[named] = struct{}{}
+		if label, ok := p.provenance[key][SourceNonce]; ok {
+			delete(p.provenance[key], SourceNonce)
+			p.provenance[key][named] = label
+		}
+		changed = true
+	}
+	if changed {
+		p.invalidateCache()
+	}
+}
+
+// replaceNoncePlaceholders replaces every nonce placeholder that forms a whole
+// source in header with the nonce source for valueOf(name), where name is
+// empty for SourceNonce.
+func replaceNoncePlaceholders(header string, valueOf func(name string) string) string {
+	return replaceTokens(header, func(token, name string, whole bool) string {
+		if !whole || !isNoncePlaceholder(token) {
+			return token
+		}
+		return substituteNonce(token, valueOf(strings.TrimPrefix(strings.TrimPrefix(name, "nonce"), ":")))
+	})
+}
+
+// substituteNonce returns the nonce source for value, or the placeholder
+// wrapped as a nonce source if value is blank.
+func substituteNonce(placeholder, value string) string {
+	if v := strings.TrimSpace(value); v != "" {
+		return Nonce(v)
+	}
+	return Nonce(placeholder)
+}
+
+// CompilableWithoutNonce reports whether the policy compiles to the same
+// header on every request, because it has no nonce placeholder and relies on
+// hashes, or on no inline content at all. Such a header can be cached and
+// compresses well under HTTP/2 header compression, which per-request nonces
+// defeat. It is a decision helper: a policy that uses nonces is not rewritten,
+// since removing them, as WithoutNonce does, would block the inline content
+// they allow.
+func (p *Policy) CompilableWithoutNonce() bool {
+	_, _, needsNonce := p.peekCache()
+	return !needsNonce
+}
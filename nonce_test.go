@@ -0,0 +1,276 @@
+package csp
+
+import (
+	"context"
+	"html/template"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestPolicy_WithoutNonce verifies that WithoutNonce strips nonce placeholders
+// from a clone, removes emptied directives, and leaves the original untouched.
+func TestPolicy_WithoutNonce(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceSelf, SourceNonce)
+	p.Add(StyleSrc, SourceNonce)
+	original := p.Compile("abc")
+
+	stripped := p.WithoutNonce()
+
+	expected := "default-src 'self'; script-src 'self'"
+	if got := stripped.Compile("abc"); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if got := p.Compile("abc"); got != original {
+		t.Errorf("original policy changed:\nexpected: %s\ngot:      %s", original, got)
+	}
+}
+
+// TestPolicy_WithoutNonce_ReportOnly verifies that a report-only directive
+// emptied by WithoutNonce leaves no mark behind, so that adding it again
+// enforces it.
+func TestPolicy_WithoutNonce_ReportOnly(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.AddReportOnly(StyleSrc, SourceNonce)
+	p.AddFrom("theme", StyleSrc, SourceNonce)
+
+	stripped := p.WithoutNonce()
+	stripped.Add(StyleSrc, SourceSelf)
+
+	enforced, reportOnly := stripped.CompileSplit()
+	expected := "default-src 'self'; style-src 'self'"
+	if enforced != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, enforced)
+	}
+	if reportOnly != "" {
+		t.Errorf("report-only header = %q, want empty", reportOnly)
+	}
+	if labels, ok := stripped.provenance[StyleSrc]; ok {
+		t.Errorf("provenance[%s] = %v, want none", StyleSrc, labels)
+	}
+	if _, report := p.CompileSplit("abc"); report == "" {
+		t.Errorf("original policy lost its report-only directive")
+	}
+}
+
+// TestNonceAttr verifies the formatting and escaping of nonce attributes.
+func TestNonceAttr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		nonce string
+		want  template.HTMLAttr
+	}{
+		{"raw base64 nonce", "abc123+/=", `nonce="abc123+/="`},
+		{"quoted source form", "'nonce-abc'", `nonce="abc"`},
+		{"quote is escaped", `a"b`, `nonce="a&#34;b"`},
+		{"markup is escaped", "<x>", `nonce="&lt;x&gt;"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := NonceAttr(tt.nonce); got != tt.want {
+				t.Errorf("NonceAttr(%q) = %q, want %q", tt.nonce, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNonceAttrFromContext verifies retrieving the nonce attribute from a
+// request context and rendering it in a template.
+func TestNonceAttrFromContext(t *testing.T) {
+	t.Parallel()
+
+	if got := NonceAttrFromContext(context.Background()); got != "" {
+		t.Errorf("NonceAttrFromContext(empty) = %q, want empty", got)
+	}
+
+	ctx := WithNonce(context.Background(), "r4nd0m")
+	if nonce, ok := NonceFromContext(ctx); !ok || nonce != "r4nd0m" {
+		t.Errorf("NonceFromContext() = %q, %v, want %q, true", nonce, ok, "r4nd0m")
+	}
+
+	tmpl := template.Must(template.New("").Parse(`<script {{.}}></script>`))
+	var b strings.Builder
+	if err := tmpl.Execute(&b, NonceAttrFromContext(ctx)); err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	if want := `<script nonce="r4nd0m"></script>`; b.String() != want {
+		t.Errorf("rendered %q, want %q", b.String(), want)
+	}
+}
+
+// TestPolicy_CompileNamed verifies substitution of named and shared nonce
+// placeholders.
+func TestPolicy_CompileNamed(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, NamedNonce("scripts"))
+	p.Add(StyleSrc, NamedNonce(" styles "), SourceNonce)
+
+	tests := []struct {
+		name     string
+		nonces   map[string]string
+		expected string
+	}{
+		{
+			name:     "all supplied",
+			nonces:   map[string]string{"scripts": "a", "styles": "b", "": "c"},
+			expected: "script-src 'nonce-a'; style-src 'nonce-b' 'nonce-c'",
+		},
+		{
+			name:     "missing names",
+			nonces:   map[string]string{"scripts": "a", "styles": " "},
+			expected: "script-src 'nonce-a'; style-src 'nonce-{{nonce:styles}}' 'nonce-{{nonce}}'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.CompileNamed(tt.nonces); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+
+	expected := "script-src 'nonce-x'; style-src 'nonce-x' 'nonce-x'"
+	if got := p.Compile("x"); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if got := p.WithoutNonce().Compile(); got != "" {
+		t.Errorf("WithoutNonce() compiled to %q, want empty", got)
+	}
+}
+
+// TestPolicy_UseSeparateNonces verifies that each directive using the shared
+// nonce gets its own named placeholder.
+func TestPolicy_UseSeparateNonces(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, SourceNonce)
+	p.Add(StyleSrc, SourceNonce)
+	p.Add(ImgSrc, SourceSelf)
+	p.UseSeparateNonces()
+
+	if got := p.SourcesByKind(ScriptSrc, KindNonce); !slices.Equal(got, []string{NamedNonce(ScriptSrc)}) {
+		t.Errorf("script-src nonces = %v, want [%s]", got, NamedNonce(ScriptSrc))
+	}
+	if got := p.SourcesByKind(StyleSrc, KindNonce); !slices.Equal(got, []string{NamedNonce(StyleSrc)}) {
+		t.Errorf("style-src nonces = %v, want [%s]", got, NamedNonce(StyleSrc))
+	}
+
+	expected := "img-src 'self'; script-src 'self' 'nonce-s1'; style-src 'nonce-s2'"
+	if got := p.CompileNamed(map[string]string{ScriptSrc: "s1", StyleSrc: "s2"}); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
+
+// TestPolicy_CompileWithFreshNonce verifies that a fresh nonce is generated
+// and injected only for policies that need one.
+func TestPolicy_CompileWithFreshNonce(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, SourceNonce)
+
+	header, nonce, err := p.CompileWithFreshNonce()
+	if err != nil {
+		t.Fatalf("CompileWithFreshNonce() unexpected error: %v", err)
+	}
+	if len(nonce) != DefaultNonceLength {
+		t.Errorf("nonce %q has length %d, want %d", nonce, len(nonce), DefaultNonceLength)
+	}
+	if expected := "script-src 'self' " + Nonce(nonce); header != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, header)
+	}
+	if _, again, _ := p.CompileWithFreshNonce(); again == nonce {
+		t.Errorf("CompileWithFreshNonce() returned the same nonce twice: %q", nonce)
+	}
+
+	static := New()
+	static.Add(ScriptSrc, SourceSelf)
+	header, nonce, err = static.CompileWithFreshNonce()
+	if err != nil || nonce != "" || header != "script-src 'self'" {
+		t.Errorf("CompileWithFreshNonce() = %q, %q, %v; want header without nonce", header, nonce, err)
+	}
+}
+
+// TestPolicy_CompilableWithoutNonce verifies which policies compile to a
+// stable header.
+func TestPolicy_CompilableWithoutNonce(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sources  []string
+		expected bool
+	}{
+		{"hash only", []string{SourceSelf, "'sha256-eHl6'"}, true},
+		{"no inline", []string{SourceSelf}, true},
+		{"literal nonce", []string{"'nonce-static'"}, true},
+		{"nonce placeholder", []string{SourceSelf, SourceNonce}, false},
+		{"named nonce", []string{NamedNonce("scripts")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ScriptSrc, tt.sources...)
+			if got := p.CompilableWithoutNonce(); got != tt.expected {
+				t.Errorf("CompilableWithoutNonce() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestValidateNonce verifies the format and length checks on nonces.
+func TestValidateNonce(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		nonce   string
+		wantErr bool
+	}{
+		{"standard base64", "B3nh1LfcP7/T8aR4y1a+5A==", false},
+		{"quoted source form", "'nonce-B3nh1LfcP7/T8aR4y1a+5A=='", false},
+		{"url-safe without padding", "B3nh1LfcP7_T8aR4y1a-5A", false},
+		{"longer nonce", "c2VjdXJlLXJhbmRvbS1ub25jZS12YWx1ZQ==", false},
+		{"too short", "YWJjZA==", true},
+		{"not base64", "not a nonce at all!!", true},
+		{"empty", " ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := ValidateNonce(tt.nonce); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateNonce(%q) error = %v, wantErr %v", tt.nonce, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("fresh nonce", func(t *testing.T) {
+		t.Parallel()
+		nonce, err := newNonce()
+		if err != nil {
+			t.Fatalf("newNonce() unexpected error: %v", err)
+		}
+		if err := ValidateNonce(nonce); err != nil {
+			t.Errorf("ValidateNonce(%q) unexpected error: %v", nonce, err)
+		}
+	})
+}
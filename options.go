@@ -0,0 +1,193 @@
+package csp
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// CompileOptions controls the layout of the header produced by CompileWith.
+// The zero value yields the same output as Compile.
+type CompileOptions struct {
+	// SecurityPriorityOrder emits the most security-relevant directives first,
+	// in the order of securityPriority, followed by the remaining directives
+	// in alphabetical order. It is intended for human-facing logs and debug
+	// output rather than for deterministic comparison.
+	SecurityPriorityOrder bool
+
+	// CanonicalSchemes emits the scheme sources of each directive in the
+	// fixed order of canonicalSchemes, after quoted sources such as keywords,
+	// nonces and hashes and before host sources. Unknown schemes follow the
+	// known ones alphabetically.
+	CanonicalSchemes bool
+
+	// ReportingFirst emits report-to and report-uri, in that order, before
+	// all other directives, for legacy log parsers that expect reporting
+	// first. The other directives keep the order they would otherwise have.
+	ReportingFirst bool
+}
+
+// securityPriority lists the directives emitted first under
+// CompileOptions.SecurityPriorityOrder, most important first.
+var securityPriority = []string{DefaultSrc, ScriptSrc, ObjectSrc, BaseURI, FrameAncestors}
+
+// reportingDirectives lists the directives emitted first under
+// CompileOptions.ReportingFirst.
+var reportingDirectives = []string{ReportTo, ReportURI}
+
+// canonicalSchemes lists scheme sources in the order emitted under
+// CompileOptions.CanonicalSchemes.
+var canonicalSchemes = []string{
+	SchemeHTTPS, SchemeHTTP, "wss:", "ws:", SchemeData, SchemeBlob, SchemeFile, SchemeMedia,
+}
+
+// CompileWith generates the CSP header string like Compile, with the layout
+// adjusted by opts. The result is built on every call and is not cached.
+func (p *Policy) CompileWith(opts CompileOptions, nonce ...string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	directiveKeys := sortedKeys(p.directives)
+	if opts.SecurityPriorityOrder {
+		directiveKeys = prioritize(directiveKeys, securityPriority)
+	}
+	if opts.ReportingFirst {
+		directiveKeys = prioritize(directiveKeys, reportingDirectives)
+	}
+	sortSources := slices.Sort[[]string]
+	if opts.CanonicalSchemes {
+		sortSources = sortSchemesCanonically
+	}
+	return p.compileKeysUnsafe(directiveKeys, sortSources, nonce)
+}
+
+// CompileWithExtra generates the CSP header string like Compile, with the
+// sources in extra added for this call only, such as a per-tenant CDN host.
+// The extra sources are merged by union per directive: a directive already in
+// the policy keeps its own sources and gains the extra ones, and a directive
+// not in the policy is added with just the extra sources. Names and sources
+// are normalized and checked as by Add: an entry without sources adds only a
+// valueless directive, blocklisted sources are dropped, and sources beyond
+// the limit set by SetMaxSourcesPerDirective are dropped. Unlike with Add,
+// dropped sources are not reported by Lint. Neither the policy nor its cached
+// compilation is modified; since the result depends on extra, it is built on
+// every call rather than taken from the cache. Without extra sources, the
+// result of Compile is returned.
+func (p *Policy) CompileWithExtra(nonce string, extra map[string][]string) string {
+	if len(extra) == 0 {
+		return p.Compile(nonce)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	// Copy only the source sets that change; the others are shared read-only.
+	directives := maps.Clone(p.directives)
+	for directive, sources := range extra {
+		key := normalizeDirective(directive)
+		if key == "" {
+			continue
+		}
+		set, exists := directives[key]
+		set = maps.Clone(set)
+		if !exists {
+			set = make(map[string]struct{}, len(sources))
+		}
+		for _, source := range sources {
+			s := strings.TrimSpace(source)
+			if _, blocked := p.blocked[s]; s == "" || blocked {
+				continue
+			}
+			if _, ok := set[s]; !ok && p.maxSources > 0 && len(set) >= p.maxSources {
+				continue
+			}
+			set[s] = struct{}{}
+		}
+		if len(set) > 0 || exists || isValueless(key) {
+			directives[key] = set
+		}
+	}
+
+	var b strings.Builder
+	hasNonce := writeDirectives(&b, p.displayNames, sortedKeys(directives), func(key string) []string {
+		return sortedSources(directives[key], slices.Sort[[]string])
+	})
+	if !hasNonce {
+		return b.String()
+	}
+	return p.injectNonce(b.String(), []string{nonce})
+}
+
+// CompileFiltered generates the CSP header string like Compile, emitting only
+// the directives for which include returns true, such as all but
+// frame-ancestors for a page that is meant to be embedded. include is called
+// with normalized directive names while the policy is locked, so it must not
+// call methods of the policy. A nil include emits every directive. The result
+// is built on every call and is not cached.
+func (p *Policy) CompileFiltered(include func(directive string) bool, nonce ...string) string {
+	if include == nil {
+		return p.Compile(nonce...)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys := slices.DeleteFunc(sortedKeys(p.directives), func(key string) bool { return !include(key) })
+	return p.compileKeysUnsafe(keys, slices.Sort[[]string], nonce)
+}
+
+// prioritize returns keys reordered so that those listed in priority come
+// first, in priority order, followed by the others in their original order.
+func prioritize(keys, priority []string) []string {
+	result := make([]string, 0, len(keys))
+	for _, k := range priority {
+		if slices.Contains(keys, k) {
+			result = append(result, k)
+		}
+	}
+	for _, k := range keys {
+		if !slices.Contains(priority, k) {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// sortSchemesCanonically orders sources as quoted sources first, then scheme
+// sources in canonical order, then everything else, each group sorted
+// alphabetically unless a canonical order applies.
+func sortSchemesCanonically(sources []string) {
+	slices.SortFunc(sources, func(a, b string) int {
+		if ga, gb := schemeGroup(a), schemeGroup(b); ga != gb {
+			return ga - gb
+		}
+		return strings.Compare(a, b)
+	})
+}
+
+// schemeGroup returns the sort group of a source for sortSchemesCanonically.
+// Known schemes get distinct consecutive groups so that they keep their
+// canonical order.
+func schemeGroup(source string) int {
+	switch {
+	case strings.HasPrefix(source, "'"):
+		return 0
+	case ClassifySource(source) == KindScheme:
+		if i := slices.Index(canonicalSchemes, strings.ToLower(source)); i >= 0 {
+			return 1 + i
+		}
+		return 1 + len(canonicalSchemes)
+	default:
+		return 2 + len(canonicalSchemes)
+	}
+}
+
+// compileKeysUnsafe serializes the given directives with their sources ordered
+// by sortSources and injects the nonce. It assumes the caller holds the mutex.
+func (p *Policy) compileKeysUnsafe(directiveKeys []string, sortSources func([]string), nonce []string) string {
+	var b strings.Builder
+	if !p.writeDirectivesUnsafe(&b, directiveKeys, sortSources) {
+		return b.String()
+	}
+	return p.injectNonce(b.String(), nonce)
+}
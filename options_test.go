@@ -0,0 +1,215 @@
+package csp
+
+import "testing"
+
+// TestPolicy_CompileWith verifies the directive ordering options of CompileWith.
+func TestPolicy_CompileWith(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ImgSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceSelf, SourceNonce)
+	p.Add(FrameAncestors, SourceNone)
+	p.Add(ConnectSrc, SourceSelf)
+	p.Add(DefaultSrc, SourceNone)
+	p.Add(MediaSrc, SourceSelf, "https://a.com", SchemeData, SchemeBlob, SchemeHTTPS, "custom:")
+
+	tests := []struct {
+		name     string
+		opts     CompileOptions
+		expected string
+	}{
+		{
+			name:     "zero options match Compile",
+			opts:     CompileOptions{},
+			expected: p.Compile("abc"),
+		},
+		{
+			name: "security priority order",
+			opts: CompileOptions{SecurityPriorityOrder: true},
+			expected: "default-src 'none'; script-src 'self' 'nonce-abc'; frame-ancestors 'none'; " +
+				"connect-src 'self'; img-src 'self'; media-src 'self' blob: custom: data: https: https://a.com",
+		},
+		{
+			name: "canonical schemes",
+			opts: CompileOptions{CanonicalSchemes: true},
+			expected: "connect-src 'self'; default-src 'none'; frame-ancestors 'none'; img-src 'self'; " +
+				"media-src 'self' https: data: blob: custom: https://a.com; script-src 'self' 'nonce-abc'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.CompileWith(tt.opts, "abc"); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_CompileWith_ReportingFirst verifies that reporting directives are
+// moved to the front, alone and combined with security priority order.
+func TestPolicy_CompileWith_ReportingFirst(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ImgSrc, SourceSelf)
+	p.Add(ReportURI, "/csp")
+	p.Add(ReportTo, "csp")
+	p.Add(ScriptSrc, SourceSelf)
+
+	tests := []struct {
+		name     string
+		opts     CompileOptions
+		expected string
+	}{
+		{
+			name: "reporting first",
+			opts: CompileOptions{ReportingFirst: true},
+			expected: "report-to csp; report-uri /csp; default-src 'self'; img-src 'self'; " +
+				"script-src 'self'",
+		},
+		{
+			name: "with security priority order",
+			opts: CompileOptions{ReportingFirst: true, SecurityPriorityOrder: true},
+			expected: "report-to csp; report-uri /csp; default-src 'self'; script-src 'self'; " +
+				"img-src 'self'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.CompileWith(tt.opts); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_CompileWithExtra verifies that CompileWithExtra merges the extra
+// sources into a single compilation and leaves the policy unchanged.
+func TestPolicy_CompileWithExtra(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceSelf, SourceNonce)
+	p.Blocklist(SourceUnsafeInline)
+	base := p.Compile("abc")
+
+	tests := []struct {
+		name     string
+		extra    map[string][]string
+		expected string
+	}{
+		{
+			name:     "no extra",
+			extra:    nil,
+			expected: base,
+		},
+		{
+			name:     "union with existing directive",
+			extra:    map[string][]string{"Script-Src": {" https://tenant.example.com", SourceSelf}},
+			expected: "default-src 'self'; script-src 'self' https://tenant.example.com 'nonce-abc'",
+		},
+		{
+			name:     "new directive",
+			extra:    map[string][]string{ImgSrc: {"https://cdn.example.com"}},
+			expected: "default-src 'self'; img-src https://cdn.example.com; script-src 'self' 'nonce-abc'",
+		},
+		{
+			name:     "valueless directive",
+			extra:    map[string][]string{UpgradeInsecureRequests: nil},
+			expected: "default-src 'self'; script-src 'self' 'nonce-abc'; upgrade-insecure-requests",
+		},
+		{
+			name:     "empty and blocklisted sources are dropped",
+			extra:    map[string][]string{StyleSrc: {" ", SourceUnsafeInline}},
+			expected: base,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.CompileWithExtra("abc", tt.extra); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			if got := p.Compile("abc"); got != base {
+				t.Errorf("base policy changed:\nexpected: %s\ngot:      %s", base, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_CompileWithExtra_SourceLimit verifies that the extra sources are
+// subject to the per-directive source limit.
+func TestPolicy_CompileWithExtra_SourceLimit(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf)
+	p.SetMaxSourcesPerDirective(2)
+
+	extra := map[string][]string{
+		ScriptSrc: {SourceSelf, "https://a.com", "https://b.com"},
+		ImgSrc:    {"https://c.com", "https://d.com", "https://e.com"},
+	}
+	const expected = "img-src https://c.com https://d.com; script-src 'self' https://a.com"
+	if got := p.CompileWithExtra("", extra); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if got := p.Lint(); hasLintMessage(got, ScriptSrc, "source limit") {
+		t.Errorf("Lint() = %v, want no source limit finding", got)
+	}
+}
+
+// TestPolicy_CompileFiltered verifies that only included directives are
+// emitted and that the policy itself is unchanged.
+func TestPolicy_CompileFiltered(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceNonce)
+	p.Add(FrameAncestors, SourceNone)
+	original := p.Compile("abc")
+
+	tests := []struct {
+		name     string
+		include  func(string) bool
+		expected string
+	}{
+		{
+			name:     "without frame-ancestors",
+			include:  func(d string) bool { return d != FrameAncestors },
+			expected: "default-src 'self'; script-src 'nonce-abc'",
+		},
+		{
+			name:     "nothing",
+			include:  func(string) bool { return false },
+			expected: "",
+		},
+		{
+			name:     "nil filter",
+			include:  nil,
+			expected: original,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.CompileFiltered(tt.include, "abc"); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+
+	if got := p.Compile("abc"); got != original {
+		t.Errorf("original policy changed:\nexpected: %s\ngot:      %s", original, got)
+	}
+}
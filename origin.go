@@ -0,0 +1,135 @@
+package csp
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// WithOrigin returns a clone of the policy that records origin as the origin
+// of the documents it protects, such as "https://example.com". The origin
+// enables checks that depend on what 'self' refers to, such as Covers and the
+// Lint finding for host sources made redundant by 'self'. It is not part of the
+// compiled header. An origin that is not an absolute URL with a scheme and
+// host, and no path beyond "/", is ignored and reported by Lint. The original
+// policy is unchanged.
+func (p *Policy) WithOrigin(origin string) *Policy {
+	cloned := p.Clone()
+
+	normalized, ok := canonicalOrigin(origin)
+	if !ok {
+		cloned.noteUnsafe("", strings.TrimSpace(origin), "invalid origin; ignored")
+	}
+	cloned.origin = normalized
+	return cloned
+}
+
+// canonicalOrigin returns the serialized origin of raw, with the scheme and
+// host lowercased and a default port removed. It reports false if raw is not
+// a bare origin.
+func canonicalOrigin(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme == "" || u.Hostname() == "" || u.User != nil ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && port != defaultPorts[scheme] {
+		host += ":" + port
+	}
+	return scheme + "://" + host, true
+}
+
+// AddFromURLs adds the origins of absolute URLs, such as the asset URLs listed
+// in a bundler manifest, to a directive. Only the scheme, host and
+// non-default port of each URL are kept, so that several assets on the same
+// host add a single origin. Relative URLs, including scheme-relative ones such
+// as "//cdn.example.com/app.js", and unparsable URLs are skipped.
+func (p *Policy) AddFromURLs(directive string, urls ...string) {
+	origins := make([]string, 0, len(urls))
+	for _, raw := range urls {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			continue
+		}
+		origin, ok := canonicalOrigin(u.Scheme + "://" + u.Host)
+		if ok && !slices.Contains(origins, origin) {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) > 0 {
+		p.Add(directive, origins...)
+	}
+}
+
+// AllowedOrigins returns the concrete origins that a directive of p allows,
+// sorted alphabetically, for decisions such as choosing a CDN permitted by
+// several policies. If the directive is not set, the nearest directive in its
+// fallback list is used. Host sources contribute their origin, without path,
+// and scheme-less hosts take the scheme of the policy origin, or https if
+// none is set. 'self' contributes the policy origin set by WithOrigin and is
+// skipped otherwise. Wildcard hosts and ports, schemes, keywords, nonces and
+// hashes do not name a concrete origin and are skipped. A nil policy yields
+// nil.
+func AllowedOrigins(p *Policy, directive string) []string {
+	if p == nil {
+		return nil
+	}
+	key := normalizeDirective(directive)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var sources map[string]struct{}
+	for _, d := range fallbackChain(key) {
+		if set, ok := p.directives[d]; ok {
+			sources = set
+			break
+		}
+	}
+
+	origins := make(map[string]struct{}, len(sources))
+	for s := range sources {
+		if origin, ok := concreteOrigin(s, p.origin); ok {
+			origins[origin] = struct{}{}
+		}
+	}
+	return sortedKeys(origins)
+}
+
+// CommonAllowedOrigins returns the concrete origins that the directive allows
+// in both a and b, as reported by AllowedOrigins, sorted alphabetically.
+func CommonAllowedOrigins(a, b *Policy, directive string) []string {
+	others := AllowedOrigins(b, directive)
+	return slices.DeleteFunc(AllowedOrigins(a, directive), func(origin string) bool {
+		_, found := slices.BinarySearch(others, origin)
+		return !found
+	})
+}
+
+// concreteOrigin returns the origin named by a source, resolving 'self' to
+// self, and reports false for sources that do not name a single origin.
+func concreteOrigin(source, self string) (string, bool) {
+	if source == SourceSelf {
+		return self, self != ""
+	}
+	if ClassifySource(source) != KindHost || strings.Contains(source, "*") {
+		return "", false
+	}
+
+	raw := source
+	if !strings.Contains(raw, "://") {
+		scheme := "https"
+		if self != "" {
+			scheme, _, _ = strings.Cut(self, "://")
+		}
+		raw = scheme + "://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	return canonicalOrigin(u.Scheme + "://" + u.Host)
+}
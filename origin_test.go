@@ -0,0 +1,195 @@
+package csp
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestCanonicalOrigin verifies origin normalization and rejection of values
+// that are not bare origins.
+func TestCanonicalOrigin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{"https://example.com", "https://example.com", true},
+		{" HTTPS://Example.COM/ ", "https://example.com", true},
+		{"https://example.com:443", "https://example.com", true},
+		{"http://example.com:8080", "http://example.com:8080", true},
+		{"example.com", "", false},
+		{"https://example.com/path", "", false},
+		{"https://example.com?q=1", "", false},
+		{"https://user@example.com", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			t.Parallel()
+			got, ok := canonicalOrigin(tt.raw)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("canonicalOrigin(%q) = %q, %v, want %q, %v", tt.raw, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestPolicy_WithOrigin verifies that Lint flags host sources made redundant
+// by 'self' once the document origin is known.
+func TestPolicy_WithOrigin(t *testing.T) {
+	t.Parallel()
+
+	const msg = "'self' already allows"
+	tests := []struct {
+		name     string
+		origin   string
+		sources  []string
+		wantWarn bool
+	}{
+		{"origin alongside self", "https://example.com", []string{SourceSelf, "https://example.com"}, true},
+		{"default port alongside self", "https://example.com", []string{SourceSelf, "https://example.com:443/"}, true},
+		{"origin without self", "https://example.com", []string{"https://example.com"}, false},
+		{"other host alongside self", "https://example.com", []string{SourceSelf, "https://cdn.example.com"}, false},
+		{"host with path alongside self", "https://example.com", []string{SourceSelf, "https://example.com/js/"}, false},
+		{"invalid origin", "example.com", []string{SourceSelf, "https://example.com"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ScriptSrc, tt.sources...)
+			if hasLintMessage(p.Lint(), ScriptSrc, msg) {
+				t.Fatal("redundant 'self' reported without an origin")
+			}
+			withOrigin := p.WithOrigin(tt.origin)
+			if got := hasLintMessage(withOrigin.Lint(), ScriptSrc, msg); got != tt.wantWarn {
+				t.Errorf("redundant 'self' warning = %v, want %v", got, tt.wantWarn)
+			}
+			if got, want := withOrigin.Compile(), p.Compile(); got != want {
+				t.Errorf("\nexpected: %s\ngot:      %s", want, got)
+			}
+		})
+	}
+
+	if !hasLintMessage(New().WithOrigin("example.com").Lint(), "", "invalid origin") {
+		t.Error("invalid origin not reported by Lint")
+	}
+}
+
+// TestPolicy_AddFromURLs verifies that only deduplicated origins of absolute
+// URLs are added.
+func TestPolicy_AddFromURLs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		urls     []string
+		expected string
+	}{
+		{
+			name:     "same host",
+			urls:     []string{"https://cdn.example.com/app.js", "https://cdn.example.com/vendor/lib.js?v=2"},
+			expected: "script-src https://cdn.example.com",
+		},
+		{
+			name:     "ports and case",
+			urls:     []string{"HTTPS://CDN.example.com:443/a.js", "https://cdn.example.com:8443/b.js"},
+			expected: "script-src https://cdn.example.com https://cdn.example.com:8443",
+		},
+		{
+			name:     "relative skipped",
+			urls:     []string{"/assets/app.js", "//cdn.example.com/app.js", "app.js", "https://a.com/x.js"},
+			expected: "script-src https://a.com",
+		},
+		{
+			name:     "nothing usable",
+			urls:     []string{"/assets/app.js", "%zz"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.AddFromURLs(ScriptSrc, tt.urls...)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestAllowedOrigins verifies the concrete origins extracted from a directive.
+func TestAllowedOrigins(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, "https://fallback.example.com")
+	p.Add(ConnectSrc, SourceSelf, SourceNonce, SchemeHTTPS, "*.example.com", "https://api.example.com:*",
+		"https://api.example.com/v1/", "HTTPS://API.example.com:443", "cdn.example.com")
+
+	tests := []struct {
+		name      string
+		policy    *Policy
+		directive string
+		expected  []string
+	}{
+		{
+			name:      "without origin",
+			policy:    p,
+			directive: ConnectSrc,
+			expected:  []string{"https://api.example.com", "https://cdn.example.com"},
+		},
+		{
+			name:      "with origin",
+			policy:    p.WithOrigin("http://app.example.com"),
+			directive: ConnectSrc,
+			expected:  []string{"http://app.example.com", "http://cdn.example.com", "https://api.example.com"},
+		},
+		{
+			name:      "fallback",
+			policy:    p,
+			directive: ImgSrc,
+			expected:  []string{"https://fallback.example.com"},
+		},
+		{
+			name:      "not set",
+			policy:    New(),
+			directive: ImgSrc,
+			expected:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := AllowedOrigins(tt.policy, tt.directive); !slices.Equal(got, tt.expected) {
+				t.Errorf("\nexpected: %v\ngot:      %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestCommonAllowedOrigins verifies the intersection of two overlapping
+// connect-src lists.
+func TestCommonAllowedOrigins(t *testing.T) {
+	t.Parallel()
+
+	a := New()
+	a.Add(ConnectSrc, "https://a.example.com", "https://shared.example.com", "https://both.example.com/x")
+	b := New()
+	b.Add(ConnectSrc, "https://shared.example.com", "https://b.example.com", "https://both.example.com")
+
+	expected := []string{"https://both.example.com", "https://shared.example.com"}
+	if got := CommonAllowedOrigins(a, b, ConnectSrc); !slices.Equal(got, expected) {
+		t.Errorf("\nexpected: %v\ngot:      %v", expected, got)
+	}
+	if got := CommonAllowedOrigins(a, nil, ConnectSrc); len(got) != 0 {
+		t.Errorf("CommonAllowedOrigins(a, nil) = %v, want none", got)
+	}
+}
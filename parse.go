@@ -0,0 +1,261 @@
+package csp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parse builds a Policy from a serialized CSP header value such as
+// "default-src 'self'; script-src 'self' https://cdn.example.com".
+// Directive names are normalized and sources are deduplicated exactly as by Add.
+// A directive that appears more than once is merged by taking the union of
+// its sources; use ParseStrict to reject such headers instead.
+// It returns an error if the header contains a malformed directive name,
+// a comma (which would separate multiple policies), or a directive that
+// requires sources but has none.
+func Parse(header string) (*Policy, error) {
+	return ParseWith(header, ParseOptions{})
+}
+
+// ParseOptions controls how ParseWith interprets a header.
+// The zero value behaves like Parse.
+type ParseOptions struct {
+	// PreserveCase keeps the original spelling of directive names, such as
+	// "Script-Src", for output. Directives are still matched
+	// case-insensitively, so methods such as Add, Remove and Covers accept
+	// any casing, duplicates differing only in case are merged, and the
+	// first spelling wins. This allows a parse-modify-serialize round trip
+	// that leaves unrelated formatting alone, at some cost: directive order
+	// still follows the lowercase names, and AsMap, Canonical, Diff and the
+	// equality checks report lowercase names. Directives removed and added
+	// again, as well as all directives after SetAll, use lowercase names.
+	PreserveCase bool
+}
+
+// ParseWith is like Parse, with the parsing adjusted by opts.
+func ParseWith(header string, opts ParseOptions) (*Policy, error) {
+	p := New()
+	if err := parseInto(p, header, false, opts); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParseStrict is like Parse but returns an error if a directive appears more
+// than once. Browsers ignore every occurrence after the first, so duplicates
+// in an incoming header usually indicate a configuration mistake.
+func ParseStrict(header string) (*Policy, error) {
+	p := New()
+	if err := parseInto(p, header, true, ParseOptions{}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// AddRaw parses a CSP header fragment and merges its directives into the
+// policy, taking the union of sources for directives present in both.
+// If the fragment is malformed, an error is returned and the policy is left
+// unchanged.
+func (p *Policy) AddRaw(header string) error {
+	parsed, err := Parse(header)
+	if err != nil {
+		return err
+	}
+	p.Merge(parsed)
+	return nil
+}
+
+// ParseReader is like Parse but reads the header from r, scanning it one token
+// at a time through a small buffer, so that a header read from a file or a
+// network stream need not be loaded into a single string first. Each source
+// is added to its directive as soon as it is scanned. Directives are checked
+// as by Parse, and the result and errors equal those of Parse for the same
+// input; since Parse reports a comma anywhere in the header first, the input
+// is read to the end even after a malformed directive. A single source longer
+// than bufio.MaxScanTokenSize is reported as an error.
+func ParseReader(r io.Reader) (*Policy, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanPolicyTokens)
+
+	sp := streamParser{p: New()}
+	for scanner.Scan() {
+		token := scanner.Bytes()
+		if bytes.IndexByte(token, ',') >= 0 {
+			return nil, errors.New("header contains ',' (multiple policies are not supported)")
+		}
+		sp.token(token)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	sp.endDirective()
+	if sp.err != nil {
+		return nil, sp.err
+	}
+	return sp.p, nil
+}
+
+// streamParser builds a policy for ParseReader from a sequence of tokens,
+// applying the same checks as parseDirective.
+type streamParser struct {
+	p       *Policy
+	key     string              // Current directive; empty between directives.
+	sources map[string]struct{} // Sources of the current directive.
+	count   int                 // Number of sources given for the current directive.
+	err     error               // First error found; later tokens are ignored.
+}
+
+// token handles a single token, which is either ';', a directive name or a
+// source of the current directive.
+func (sp *streamParser) token(token []byte) {
+	switch {
+	case sp.err != nil:
+	case len(token) == 1 && token[0] == ';':
+		sp.endDirective()
+	case sp.key == "":
+		sp.startDirective(string(token))
+	default:
+		sp.count++
+		// Look the source up first, which does not allocate, so that only
+		// new sources are copied.
+		if _, ok := sp.sources[string(token)]; !ok {
+			sp.p.addSourceUnsafe(sp.key, sp.sources, string(token))
+		}
+	}
+}
+
+// startDirective begins the directive with the given name.
+func (sp *streamParser) startDirective(name string) {
+	if !isValidDirectiveName(name) {
+		sp.err = fmt.Errorf("invalid directive name %q", name)
+		return
+	}
+	sp.key = normalizeDirective(name)
+	sp.count = 0
+	sp.sources = sp.p.directives[sp.key]
+	if sp.sources == nil {
+		sp.sources = make(map[string]struct{})
+	}
+}
+
+// endDirective completes the current directive, if any, and adds it to the
+// policy unless it is empty.
+func (sp *streamParser) endDirective() {
+	if sp.err != nil || sp.key == "" {
+		return
+	}
+	if sp.count == 0 && !isValueless(sp.key) {
+		sp.err = fmt.Errorf("directive %q has no sources", sp.key)
+		return
+	}
+	if len(sp.sources) > 0 || sp.count == 0 {
+		sp.p.directives[sp.key] = sp.sources
+	}
+	sp.key, sp.sources = "", nil
+}
+
+// scanPolicyTokens is a bufio.SplitFunc that splits a policy into
+// whitespace-separated words, with each ';' returned as a token of its own.
+func scanPolicyTokens(data []byte, atEOF bool) (int, []byte, error) {
+	// Skip leading whitespace.
+	start := 0
+	for start < len(data) {
+		isSpace, width := spaceAt(data, start)
+		if !isSpace {
+			break
+		}
+		start += width
+	}
+	if start < len(data) && data[start] == ';' {
+		return start + 1, data[start : start+1], nil
+	}
+
+	// Scan until whitespace or ';', marking the end of the word.
+	for i := start; i < len(data); {
+		isSpace, width := spaceAt(data, i)
+		if isSpace || data[i] == ';' {
+			return i, data[start:i], nil
+		}
+		i += width
+	}
+	if atEOF && len(data) > start {
+		return len(data), data[start:], nil
+	}
+	// Request more data.
+	return start, nil, nil
+}
+
+// spaceAt reports whether data[i:] starts with a whitespace character, as
+// defined by unicode.IsSpace, and returns the width of the character.
+func spaceAt(data []byte, i int) (bool, int) {
+	if c := data[i]; c < utf8.RuneSelf {
+		return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f', 1
+	}
+	r, width := utf8.DecodeRune(data[i:])
+	return unicode.IsSpace(r), width
+}
+
+// parseInto parses header and adds its directives to p.
+// If rejectDuplicates is true, a repeated directive is an error.
+// p must not be shared yet, as directives are added one at a time.
+func parseInto(p *Policy, header string, rejectDuplicates bool, opts ParseOptions) error {
+	if strings.Contains(header, ",") {
+		return errors.New("header contains ',' (multiple policies are not supported)")
+	}
+
+	seen := make(map[string]struct{})
+	for _, token := range strings.Split(header, ";") {
+		fields := strings.Fields(token)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := parseDirective(p, fields, seen, rejectDuplicates, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDirective validates a single directive, given as its name followed by
+// its sources, and adds it to p. seen records the directives parsed so far.
+// ParseReader applies the same checks through streamParser.
+func parseDirective(p *Policy, fields []string, seen map[string]struct{}, rejectDuplicates bool, opts ParseOptions) error {
+	name := fields[0]
+	if !isValidDirectiveName(name) {
+		return fmt.Errorf("invalid directive name %q", name)
+	}
+
+	key := normalizeDirective(name)
+	if len(fields) == 1 && !isValueless(key) {
+		return fmt.Errorf("directive %q has no sources", key)
+	}
+	if _, dup := seen[key]; dup && rejectDuplicates {
+		return fmt.Errorf("duplicate directive %q", key)
+	}
+	if _, dup := seen[key]; !dup && opts.PreserveCase && name != key {
+		if p.displayNames == nil {
+			p.displayNames = make(map[string]string)
+		}
+		p.displayNames[key] = name
+	}
+	seen[key] = struct{}{}
+	p.Add(key, fields[1:]...)
+	return nil
+}
+
+// isValidDirectiveName checks that name consists of ASCII letters, digits and dashes.
+func isValidDirectiveName(name string) bool {
+	for i := range len(name) {
+		c := name[i]
+		if !isASCIILetter(c) && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,276 @@
+package csp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestParse verifies that Parse produces a policy that compiles to the
+// canonical form of the input header, and rejects malformed headers.
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "empty header",
+			header:   "",
+			expected: "",
+		},
+		{
+			name:     "single directive",
+			header:   "default-src 'self'",
+			expected: "default-src 'self'",
+		},
+		{
+			name:     "multiple directives are normalized",
+			header:   "  Script-Src https://b.com 'self'  ;default-src 'none';;",
+			expected: "default-src 'none'; script-src 'self' https://b.com",
+		},
+		{
+			name:     "valueless directive",
+			header:   "upgrade-insecure-requests; default-src 'self'",
+			expected: "default-src 'self'; upgrade-insecure-requests",
+		},
+		{
+			name:     "duplicate directives are merged",
+			header:   "script-src 'self'; script-src https://a.com",
+			expected: "script-src 'self' https://a.com",
+		},
+		{
+			name:    "invalid directive name",
+			header:  "script_src 'self'",
+			wantErr: true,
+		},
+		{
+			name:    "multiple policies",
+			header:  "default-src 'self', script-src 'self'",
+			wantErr: true,
+		},
+		{
+			name:    "directive without sources",
+			header:  "script-src",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := Parse(tt.header)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) expected error, got none", tt.header)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.header, err)
+			}
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPolicy_AddRaw verifies that AddRaw merges a parsed fragment into the
+// policy and leaves it unchanged on error.
+func TestPolicy_AddRaw(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merges sources", func(t *testing.T) {
+		t.Parallel()
+		p := New()
+		p.Add(ScriptSrc, SourceSelf)
+		if err := p.AddRaw("script-src https://extra.com"); err != nil {
+			t.Fatalf("AddRaw() unexpected error: %v", err)
+		}
+		expected := "script-src 'self' https://extra.com"
+		if got := p.Compile(); got != expected {
+			t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+		}
+	})
+
+	t.Run("malformed input leaves policy unchanged", func(t *testing.T) {
+		t.Parallel()
+		p := New()
+		p.Add(ScriptSrc, SourceSelf)
+		if err := p.AddRaw("img-src data:; bad_name 'self'"); err == nil {
+			t.Fatal("AddRaw() expected error, got none")
+		}
+		expected := "script-src 'self'"
+		if got := p.Compile(); got != expected {
+			t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+		}
+	})
+}
+
+// TestParseStrict verifies that ParseStrict rejects duplicate directives that
+// Parse merges.
+func TestParseStrict(t *testing.T) {
+	t.Parallel()
+
+	const header = "script-src 'self'; Script-Src https://a.com"
+
+	t.Run("Parse merges duplicates", func(t *testing.T) {
+		t.Parallel()
+		p, err := Parse(header)
+		if err != nil {
+			t.Fatalf("Parse(%q) unexpected error: %v", header, err)
+		}
+		expected := "script-src 'self' https://a.com"
+		if got := p.Compile(); got != expected {
+			t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+		}
+	})
+
+	t.Run("ParseStrict rejects duplicates", func(t *testing.T) {
+		t.Parallel()
+		if _, err := ParseStrict(header); err == nil {
+			t.Errorf("ParseStrict(%q) expected error, got none", header)
+		}
+	})
+
+	t.Run("ParseStrict accepts distinct directives", func(t *testing.T) {
+		t.Parallel()
+		p, err := ParseStrict("default-src 'self'; script-src 'self'")
+		if err != nil {
+			t.Fatalf("ParseStrict() unexpected error: %v", err)
+		}
+		expected := "default-src 'self'; script-src 'self'"
+		if got := p.Compile(); got != expected {
+			t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+		}
+	})
+}
+
+// TestParseWith_PreserveCase verifies that directive names keep their
+// original spelling under PreserveCase while still matching
+// case-insensitively.
+func TestParseWith_PreserveCase(t *testing.T) {
+	t.Parallel()
+
+	const header = "Script-Src 'self'; DEFAULT-SRC 'none'; script-src https://cdn.example.com"
+
+	t.Run("preserved", func(t *testing.T) {
+		t.Parallel()
+		p, err := ParseWith(header, ParseOptions{PreserveCase: true})
+		if err != nil {
+			t.Fatalf("ParseWith() unexpected error: %v", err)
+		}
+		p.Add("script-src", "https://other.example.com")
+		p.Add(ImgSrc, SchemeData)
+
+		expected := "DEFAULT-SRC 'none'; img-src data:; Script-Src 'self' https://cdn.example.com https://other.example.com"
+		if got := p.Compile(); got != expected {
+			t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+		}
+
+		p.Remove("Default-Src")
+		p.Add(DefaultSrc, SourceSelf)
+		if got := p.Clone().Compile(); !strings.HasPrefix(got, "default-src 'self';") {
+			t.Errorf("re-added directive = %q, want lowercase default-src", got)
+		}
+	})
+
+	t.Run("normalized by default", func(t *testing.T) {
+		t.Parallel()
+		p, err := ParseWith(header, ParseOptions{})
+		if err != nil {
+			t.Fatalf("ParseWith() unexpected error: %v", err)
+		}
+		expected := "default-src 'none'; script-src 'self' https://cdn.example.com"
+		if got := p.Compile(); got != expected {
+			t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+		}
+	})
+}
+
+// TestParseReader verifies that ParseReader produces the same policy as
+// Parse, and rejects the same malformed headers with the same errors.
+func TestParseReader(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"default-src 'self'; script-src 'self' https://cdn.example.com",
+		"  Script-Src   'self'\t'unsafe-inline' ;; img-src data: ;",
+		"script-src a.com; script-src b.com a.com",
+		"upgrade-insecure-requests; sandbox",
+		"default-src 'self'",
+		"",
+		"default-src 'self', script-src 'self'",
+		"script_src 'self'",
+		"default-src; script-src 'self'",
+		"script-src 'self'; img-src",
+		"script-src a.com; script-src",
+		"script-src a.com;script-src b.com;;",
+		"sandbox; sandbox allow-scripts",
+		"img-src\u00a0data:\u2003https:",
+		"script-src 'self' 'unsafe-inline',",
+		"bad_name x; script-src a, b",
+		"script-src; img-src a.com,b.com",
+		"img-src a.com; bad_name x; script-src",
+		"script-src; bad_name x",
+		"sandbox allow-scripts; sandbox; script-src a.com a.com",
+	}
+
+	for _, header := range tests {
+		t.Run(header, func(t *testing.T) {
+			t.Parallel()
+			want, wantErr := Parse(header)
+			got, err := ParseReader(strings.NewReader(header))
+			if (err != nil) != (wantErr != nil) || (err != nil && err.Error() != wantErr.Error()) {
+				t.Fatalf("ParseReader() error = %v, Parse() error = %v", err, wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !got.Equal(want) {
+				t.Errorf("\nexpected: %s\ngot:      %s", want.Compile(), got.Compile())
+			}
+		})
+	}
+}
+
+// TestParseReader_SmallReads verifies that tokens split across reads are
+// reassembled.
+func TestParseReader_SmallReads(t *testing.T) {
+	t.Parallel()
+
+	header := largeHeader(200)
+	want, err := Parse(header)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	got, err := ParseReader(iotest.OneByteReader(strings.NewReader(header)))
+	if err != nil {
+		t.Fatalf("ParseReader() unexpected error: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("ParseReader() over one-byte reads differs from Parse()")
+	}
+}
+
+// largeHeader returns a header with n host sources spread over a few
+// directives, for parser tests and benchmarks.
+func largeHeader(n int) string {
+	directives := []string{ConnectSrc, ImgSrc, ScriptSrc, StyleSrc}
+	var b strings.Builder
+	for i, directive := range directives {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(directive)
+		for j := i; j < n; j += len(directives) {
+			fmt.Fprintf(&b, " https://host%d.example.com", j)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package csp
+
+// Profile is a named set of adjustments that ApplyProfile layers over an
+// existing policy.
+type Profile int
+
+// These are the profiles recognized by ApplyProfile. The exact effect of each
+// is documented on ApplyProfile.
+const (
+	ProfileStrict  Profile = iota + 1 // Locks down plugins, base URLs, framing and mixed content.
+	ProfileRelaxed                    // Adds same-origin defaults without restricting existing sources.
+	ProfileAPIOnly                    // Blocks all content except same-origin fetches, for API responses.
+)
+
+// String returns a lowercase name for the profile.
+func (pr Profile) String() string {
+	switch pr {
+	case ProfileStrict:
+		return "strict"
+	case ProfileRelaxed:
+		return "relaxed"
+	case ProfileAPIOnly:
+		return "api-only"
+	default:
+		return "unknown"
+	}
+}
+
+// ApplyProfile adjusts the policy according to a profile, keeping the sources
+// of directives the profile does not touch. "Replaces" below means the
+// directive's sources are overwritten; "if unset" means an existing directive
+// is left alone.
+//
+// ProfileStrict replaces object-src and base-uri with 'none', sets
+// frame-ancestors to 'self' if unset, and adds upgrade-insecure-requests.
+//
+// ProfileRelaxed sets default-src to 'self' and object-src to 'none', each if
+// unset.
+//
+// ProfileAPIOnly replaces default-src and frame-ancestors with 'none' and
+// adds 'self' to connect-src, removing 'none' from it if present.
+//
+// Unknown profiles leave the policy unchanged.
+func (p *Policy) ApplyProfile(profile Profile) {
+	p.checkSealed()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch profile {
+	case ProfileStrict:
+		p.replaceUnsafe(ObjectSrc, SourceNone)
+		p.replaceUnsafe(BaseURI, SourceNone)
+		p.defaultUnsafe(FrameAncestors, SourceSelf)
+		p.replaceUnsafe(UpgradeInsecureRequests)
+	case ProfileRelaxed:
+		p.defaultUnsafe(DefaultSrc, SourceSelf)
+		p.defaultUnsafe(ObjectSrc, SourceNone)
+	case ProfileAPIOnly:
+		p.replaceUnsafe(DefaultSrc, SourceNone)
+		p.replaceUnsafe(FrameAncestors, SourceNone)
+		delete(p.directives[ConnectSrc], SourceNone)
+		p.addSourcesUnsafe(ConnectSrc, []string{SourceSelf})
+	default:
+		return
+	}
+	p.invalidateCache()
+}
+
+// replaceUnsafe replaces the sources of a directive, subject to the source
+// limit and blocklist. It assumes the caller holds the mutex.
+func (p *Policy) replaceUnsafe(directive string, sources ...string) {
+	set := make(map[string]struct{}, len(sources))
+	for _, s := range sources {
+		p.addSourceUnsafe(directive, set, s)
+	}
+	p.directives[directive] = set
+}
+
+// defaultUnsafe sets the sources of a directive unless it is already set.
+// It assumes the caller holds the mutex.
+func (p *Policy) defaultUnsafe(directive string, sources ...string) {
+	if _, ok := p.directives[directive]; !ok {
+		p.replaceUnsafe(directive, sources...)
+	}
+}
+
+// APIServer returns a new policy for servers that only return data such as
+// JSON and serve no HTML, where nothing should ever load:
+//
+//	base-uri 'none'; default-src 'none'; frame-ancestors 'none'
+//
+// frame-ancestors and base-uri are set explicitly because they do not fall
+// back to default-src. Unlike ProfileAPIOnly, which keeps same-origin fetches
+// for pages that call the API, it allows nothing at all.
+func APIServer() *Policy {
+	p := New()
+	p.Add(DefaultSrc, SourceNone)
+	p.Add(FrameAncestors, SourceNone)
+	p.Add(BaseURI, SourceNone)
+	return p
+}
@@ -0,0 +1,70 @@
+package csp
+
+import "testing"
+
+// TestPolicy_ApplyProfile verifies the documented effect of each profile on
+// an existing policy.
+func TestPolicy_ApplyProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		profile  Profile
+		expected string
+	}{
+		{
+			name:    "strict",
+			profile: ProfileStrict,
+			expected: "base-uri 'none'; connect-src 'none'; frame-ancestors 'self'; " +
+				"object-src 'none'; script-src 'self' https://cdn.example.com; upgrade-insecure-requests",
+		},
+		{
+			name:     "relaxed",
+			profile:  ProfileRelaxed,
+			expected: "connect-src 'none'; default-src 'self'; object-src 'self'; script-src 'self' https://cdn.example.com",
+		},
+		{
+			name:    "api only",
+			profile: ProfileAPIOnly,
+			expected: "connect-src 'self'; default-src 'none'; frame-ancestors 'none'; " +
+				"object-src 'self'; script-src 'self' https://cdn.example.com",
+		},
+		{
+			name:     "unknown",
+			profile:  Profile(0),
+			expected: "connect-src 'none'; object-src 'self'; script-src 'self' https://cdn.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ScriptSrc, SourceSelf, "https://cdn.example.com")
+			p.Add(ObjectSrc, SourceSelf)
+			p.Add(ConnectSrc, SourceNone)
+			p.Compile() // Prime the cache to verify invalidation
+			p.ApplyProfile(tt.profile)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestAPIServer verifies the exact output of the API server preset and that
+// each call returns an independent policy.
+func TestAPIServer(t *testing.T) {
+	t.Parallel()
+
+	p := APIServer()
+	expected := "base-uri 'none'; default-src 'none'; frame-ancestors 'none'"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+
+	p.Add(ImgSrc, SourceSelf)
+	if got := APIServer().Compile(); got != expected {
+		t.Errorf("APIServer() shares state between calls:\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
@@ -0,0 +1,81 @@
+package csp
+
+import (
+	"maps"
+	"strings"
+)
+
+// AddFrom works like Add and additionally records label, such as the name of
+// the module or configuration fragment making the call, as the origin of each
+// added source. Provenance reports the recorded labels. Tracking is opt-in:
+// memory for it is only allocated once AddFrom is used. If several calls add
+// the same source, the first label is kept. Set and Remove discard the labels
+// of the directive they replace or remove.
+func (p *Policy) AddFrom(label, directive string, sources ...string) {
+	p.checkSealed()
+
+	p.Add(directive, sources...)
+
+	key := normalizeDirective(directive)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	present, ok := p.directives[key]
+	if !ok {
+		return
+	}
+	if p.provenance == nil {
+		p.provenance = make(map[string]map[string]string)
+	}
+	labels, ok := p.provenance[key]
+	if !ok {
+		labels = make(map[string]string, len(sources))
+		p.provenance[key] = labels
+	}
+	for _, source := range sources {
+		s := strings.TrimSpace(source)
+		if _, added := present[s]; !added {
+			continue
+		}
+		if _, recorded := labels[s]; !recorded {
+			labels[s] = label
+		}
+	}
+}
+
+// Provenance returns the labels recorded by AddFrom, as a map from directive
+// to a map from source to label. Only sources still present in the policy are
+// included; sources added without AddFrom have no entry. The returned map is
+// a copy and can be freely modified.
+func (p *Policy) Provenance() map[string]map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make(map[string]map[string]string, len(p.provenance))
+	for directive, labels := range p.provenance {
+		present := p.directives[directive]
+		for s, label := range labels {
+			if _, ok := present[s]; !ok {
+				continue
+			}
+			if result[directive] == nil {
+				result[directive] = make(map[string]string, len(labels))
+			}
+			result[directive][s] = label
+		}
+	}
+	return result
+}
+
+// cloneProvenance returns a deep copy of provenance labels.
+func cloneProvenance(m map[string]map[string]string) map[string]map[string]string {
+	if m == nil {
+		return nil
+	}
+	cloned := make(map[string]map[string]string, len(m))
+	for directive, labels := range m {
+		cloned[directive] = maps.Clone(labels)
+	}
+	return cloned
+}
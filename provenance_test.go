@@ -0,0 +1,53 @@
+package csp
+
+import (
+	"maps"
+	"testing"
+)
+
+// TestPolicy_AddFrom verifies that sources carry the label of the first
+// fragment that added them, and that labels follow source removal.
+func TestPolicy_AddFrom(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.AddFrom("base", ScriptSrc, SourceSelf, " https://cdn.example.com")
+	p.AddFrom("analytics", "Script-Src", "https://analytics.example.com", SourceSelf)
+	p.AddFrom("images", ImgSrc, SchemeData)
+	p.Add(ImgSrc, SchemeBlob)
+	p.AddFrom("ignored", DefaultSrc)
+
+	want := map[string]map[string]string{
+		ScriptSrc: {
+			SourceSelf:                      "base",
+			"https://cdn.example.com":       "base",
+			"https://analytics.example.com": "analytics",
+		},
+		ImgSrc: {SchemeData: "images"},
+	}
+	got := p.Provenance()
+	if !maps.EqualFunc(got, want, maps.Equal[map[string]string]) {
+		t.Errorf("Provenance() = %v, want %v", got, want)
+	}
+
+	cloned := p.Clone()
+	p.Set(ImgSrc, SchemeData)
+	p.Blocklist("https://cdn.example.com")
+
+	want = map[string]map[string]string{
+		ScriptSrc: {
+			SourceSelf:                      "base",
+			"https://analytics.example.com": "analytics",
+		},
+	}
+	if got := p.Provenance(); !maps.EqualFunc(got, want, maps.Equal[map[string]string]) {
+		t.Errorf("Provenance() after changes = %v, want %v", got, want)
+	}
+	if got := cloned.Provenance(); len(got[ImgSrc]) != 1 || len(got[ScriptSrc]) != 3 {
+		t.Errorf("cloned Provenance() = %v, want the original labels", got)
+	}
+
+	if got := New().Provenance(); len(got) != 0 {
+		t.Errorf("Provenance() without AddFrom = %v, want empty", got)
+	}
+}
@@ -0,0 +1,46 @@
+package csp
+
+import "strings"
+
+// Report is the body of a CSP violation report sent to a report-uri
+// endpoint, the object under the "csp-report" key of the JSON document.
+// Only the fields needed to correlate a report with a policy are included.
+type Report struct {
+	DocumentURI        string `json:"document-uri"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+}
+
+// EffectiveDir returns the policy directive the report is attributed to, as
+// computed by EffectiveDirective from the effective-directive field or, for
+// older browsers that omit it, the violated-directive field.
+func (r *Report) EffectiveDir() string {
+	if r.EffectiveDirective != "" {
+		return EffectiveDirective(r.EffectiveDirective)
+	}
+	return EffectiveDirective(r.ViolatedDirective)
+}
+
+// EffectiveDirective maps a directive named in a violation report to the
+// directive it falls back to in a CSP Level 2 policy, for correlating reports
+// with policy entries. Level 3 directives follow the fallback table up to the
+// first level 2 directive, so script-src-elem and script-src-attr map to
+// script-src and worker-src maps to child-src; directives that would fall back
+// only to default-src are returned unchanged. The name is normalized, and any
+// sources following it, as sent in violated-directive by some browsers, are
+// ignored.
+func EffectiveDirective(violated string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(violated), " ")
+	key := normalizeDirective(name)
+	for directiveLevels[key] == 3 {
+		parent, ok := fallbackParents[key]
+		if !ok || parent == DefaultSrc {
+			break
+		}
+		key = parent
+	}
+	return key
+}
@@ -0,0 +1,79 @@
+package csp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestEffectiveDirective verifies the mapping of reported directives through
+// the fallback table.
+func TestEffectiveDirective(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		violated string
+		expected string
+	}{
+		{ScriptSrcElem, ScriptSrc},
+		{ScriptSrcAttr, ScriptSrc},
+		{StyleSrcElem, StyleSrc},
+		{WorkerSrc, ChildSrc},
+		{ScriptSrc, ScriptSrc},
+		{PrefetchSrc, PrefetchSrc},
+		{ImgSrc, ImgSrc},
+		{FrameAncestors, FrameAncestors},
+		{" Script-Src-Elem ", ScriptSrc},
+		{"script-src-elem 'self' https://cdn.example.com", ScriptSrc},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.violated, func(t *testing.T) {
+			t.Parallel()
+			if got := EffectiveDirective(tt.violated); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestReport_EffectiveDir verifies that a decoded report is attributed to the
+// directive the fallback table maps it to.
+func TestReport_EffectiveDir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "effective directive",
+			body:     `{"violated-directive":"script-src-elem","effective-directive":"script-src-elem"}`,
+			expected: ScriptSrc,
+		},
+		{
+			name:     "violated directive only",
+			body:     `{"violated-directive":"script-src-elem 'self'"}`,
+			expected: ScriptSrc,
+		},
+		{
+			name:     "empty",
+			body:     `{}`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var r Report
+			if err := json.Unmarshal([]byte(tt.body), &r); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := r.EffectiveDir(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
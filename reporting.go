@@ -0,0 +1,67 @@
+package csp
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// reportToMaxAge is the max_age, in seconds, of groups in the legacy Report-To
+// header: 126 days, as in the examples of the Reporting API drafts.
+const reportToMaxAge = 10886400
+
+// ReportingHeaders returns the reporting response headers implied by the
+// policy's report-to directive, keyed by header name. The report-to
+// directive only names endpoint groups, so endpoints maps each group name to
+// the URL reports are sent to; groups without an entry are skipped.
+//
+// The result contains a Reporting-Endpoints header, as defined by the
+// Reporting API, listing the groups in alphabetical order, e.g.
+// `csp="https://example.com/csp"`. If legacy is true, it also contains the
+// older Report-To header, a comma-separated list of JSON group objects, for
+// browsers that do not support Reporting-Endpoints. It returns an empty map
+// if report-to is not set or none of its groups has an endpoint.
+func (p *Policy) ReportingHeaders(endpoints map[string]string, legacy bool) map[string]string {
+	p.mu.RLock()
+	groups := sortedKeys(p.directives[ReportTo])
+	p.mu.RUnlock()
+
+	var modern, old []string
+	for _, group := range groups {
+		url, ok := endpoints[group]
+		if !ok || url == "" {
+			continue
+		}
+		modern = append(modern, group+"="+strconv.Quote(url))
+		if legacy {
+			old = append(old, reportToGroup(group, url))
+		}
+	}
+
+	headers := make(map[string]string, 2)
+	if len(modern) > 0 {
+		headers["Reporting-Endpoints"] = strings.Join(modern, ", ")
+	}
+	if len(old) > 0 {
+		headers["Report-To"] = strings.Join(old, ", ")
+	}
+	return headers
+}
+
+// reportToGroup returns the JSON object describing an endpoint group in the
+// legacy Report-To header.
+func reportToGroup(group, url string) string {
+	type endpoint struct {
+		URL string `json:"url"`
+	}
+	data, err := json.Marshal(struct {
+		Group     string     `json:"group"`
+		MaxAge    int        `json:"max_age"`
+		Endpoints []endpoint `json:"endpoints"`
+	}{group, reportToMaxAge, []endpoint{{url}}})
+	if err != nil {
+		// Marshaling a struct of strings and integers cannot fail.
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,82 @@
+package csp
+
+import (
+	"maps"
+	"testing"
+)
+
+// TestPolicy_ReportingHeaders verifies the reporting headers derived from the
+// report-to directive and an endpoint mapping.
+func TestPolicy_ReportingHeaders(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ReportTo, "csp", "unmapped")
+
+	endpoints := map[string]string{
+		"csp":   "https://example.com/csp",
+		"other": "https://example.com/other",
+	}
+
+	tests := []struct {
+		name      string
+		p         *Policy
+		endpoints map[string]string
+		legacy    bool
+		want      map[string]string
+	}{
+		{
+			name:      "modern only",
+			p:         p,
+			endpoints: endpoints,
+			want:      map[string]string{"Reporting-Endpoints": `csp="https://example.com/csp"`},
+		},
+		{
+			name:      "with legacy",
+			p:         p,
+			endpoints: endpoints,
+			legacy:    true,
+			want: map[string]string{
+				"Reporting-Endpoints": `csp="https://example.com/csp"`,
+				"Report-To":           `{"group":"csp","max_age":10886400,"endpoints":[{"url":"https://example.com/csp"}]}`,
+			},
+		},
+		{
+			name:      "no mapping",
+			p:         p,
+			endpoints: nil,
+			want:      map[string]string{},
+		},
+		{
+			name:      "no report-to",
+			p:         New(),
+			endpoints: endpoints,
+			want:      map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.p.ReportingHeaders(tt.endpoints, tt.legacy); !maps.Equal(got, tt.want) {
+				t.Errorf("ReportingHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicy_ReportingHeaders_Multiple verifies the ordering and separators
+// used for several groups.
+func TestPolicy_ReportingHeaders_Multiple(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ReportTo, "b", "a")
+
+	got := p.ReportingHeaders(map[string]string{"a": "https://a.example/r", "b": "https://b.example/r"}, false)
+	want := `a="https://a.example/r", b="https://b.example/r"`
+	if got["Reporting-Endpoints"] != want {
+		t.Errorf("\nexpected: %s\ngot:      %s", want, got["Reporting-Endpoints"])
+	}
+}
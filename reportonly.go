@@ -0,0 +1,165 @@
+package csp
+
+import "slices"
+
+// AddReportOnly works like Add but also marks the directive as report-only,
+// so that CompileSplit emits it in the report-only header instead of the
+// enforced one. The mark applies to the whole directive, including sources
+// added before or after the call, and is cleared only by Remove.
+//
+// Compile and the other compile methods ignore the mark and emit every
+// directive; only CompileSplit separates them.
+func (p *Policy) AddReportOnly(directive string, sources ...string) {
+	p.checkSealed()
+
+	p.Add(directive, sources...)
+
+	key := normalizeDirective(directive)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Add may have ignored the call, e.g. for a directive without sources.
+	if _, ok := p.directives[key]; !ok {
+		return
+	}
+	if p.reportOnly == nil {
+		p.reportOnly = make(map[string]struct{})
+	}
+	p.reportOnly[key] = struct{}{}
+}
+
+// CompileSplit compiles the policy into two header values: the first for the
+// Content-Security-Policy header, containing all directives not marked by
+// AddReportOnly, and the second for the Content-Security-Policy-Report-Only
+// header, containing the marked ones. Unmarked report-uri and report-to
+// directives are included in both values whenever the report-only value is
+// non-empty, so that violations of either header are reported. The nonce is
+// injected into both values.
+func (p *Policy) CompileSplit(nonce ...string) (string, string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var enforceKeys, reportKeys []string
+	for _, key := range sortedKeys(p.directives) {
+		if _, ok := p.reportOnly[key]; ok {
+			reportKeys = append(reportKeys, key)
+		} else {
+			enforceKeys = append(enforceKeys, key)
+		}
+	}
+	if len(reportKeys) > 0 {
+		for _, key := range enforceKeys {
+			if key == ReportURI || key == ReportTo {
+				reportKeys = append(reportKeys, key)
+			}
+		}
+		slices.Sort(reportKeys)
+	}
+
+	return p.compileKeysUnsafe(enforceKeys, slices.Sort[[]string], nonce),
+		p.compileKeysUnsafe(reportKeys, slices.Sort[[]string], nonce)
+}
+
+// CompareEnforceReportOnly checks a report-only policy sent alongside an
+// enforced one, typically while migrating to a stricter policy, and returns a
+// finding for every directive of enforce that is missing from reportOnly and
+// for every source that reportOnly allows but enforce does not. Such a source
+// is already blocked by the enforced policy, so the report-only policy adds
+// no reports for it and is looser than intended. report-uri and report-to are
+// only checked for presence. Sources are compared literally, with nonces
+// normalized to the SourceNonce placeholder, so a host also matched by a
+// wildcard in enforce is still reported. Findings have CheckReportOnlyMismatch
+// and SeverityWarning. A nil policy is treated as empty.
+func CompareEnforceReportOnly(enforce, reportOnly *Policy) []LintResult {
+	if enforce == nil {
+		enforce = New()
+	}
+	if reportOnly == nil {
+		reportOnly = New()
+	}
+	enforced := enforce.normalizedDirectives(normalizeNonceSource)
+	reported := reportOnly.normalizedDirectives(normalizeNonceSource)
+
+	var results []LintResult
+	for _, directive := range sortedKeys(enforced) {
+		sources, ok := reported[directive]
+		if !ok {
+			results = append(results, LintResult{
+				Check:     CheckReportOnlyMismatch,
+				Severity:  SeverityWarning,
+				Directive: directive,
+				Message:   "directive is enforced but missing from the report-only policy",
+			})
+			continue
+		}
+		if directive == ReportURI || directive == ReportTo {
+			continue
+		}
+		for _, s := range sortedKeys(sources) {
+			if _, ok := enforced[directive][s]; ok {
+				continue
+			}
+			results = append(results, LintResult{
+				Check:     CheckReportOnlyMismatch,
+				Severity:  SeverityWarning,
+				Directive: directive,
+				Source:    s,
+				Message:   "report-only policy allows a source the enforced policy blocks; it is never reported",
+			})
+		}
+	}
+	return results
+}
+
+// CompileBoth compiles an enforced policy and a report-only policy with the
+// same nonce, so that a single nonce attribute on the page satisfies both
+// headers, and returns the enforced header name and value followed by the
+// report-only header name and value. A nil policy yields an empty value, in
+// which case that header should not be sent. To send parts of one policy as
+// report-only, use AddReportOnly and CompileSplit instead.
+func CompileBoth(enforce, reportOnly *Policy, nonce string) (string, string, string, string) {
+	var enforceValue, reportValue string
+	if enforce != nil {
+		enforceValue = enforce.Compile(nonce)
+	}
+	if reportOnly != nil {
+		reportValue = reportOnly.Compile(nonce)
+	}
+	return HeaderName, enforceValue, HeaderNameReportOnly, reportValue
+}
+
+// reportOnlyIgnoredDirectives lists the directives that browsers ignore in a
+// policy delivered through the Content-Security-Policy-Report-Only header.
+var reportOnlyIgnoredDirectives = []string{Sandbox, UpgradeInsecureRequests}
+
+// ForEnforcement returns a clone of the policy prepared for the
+// Content-Security-Policy header. Every directive takes effect when enforced,
+// so none is removed; the marks set by AddReportOnly are cleared, so that
+// CompileSplit emits the whole policy as enforced. report-uri and report-to
+// are kept. The original policy is unchanged.
+func (p *Policy) ForEnforcement() *Policy {
+	cloned := p.Clone()
+	cloned.reportOnly = nil
+	return cloned
+}
+
+// ForReportOnly returns a clone of the policy prepared for the
+// Content-Security-Policy-Report-Only header. sandbox and
+// upgrade-insecure-requests are removed, since browsers ignore them in a
+// report-only policy. report-uri and report-to are kept; if the policy has
+// neither, nothing would ever be reported, which is recorded as a Lint
+// finding on the clone. The original policy is unchanged.
+func (p *Policy) ForReportOnly() *Policy {
+	cloned := p.Clone()
+	for _, directive := range reportOnlyIgnoredDirectives {
+		cloned.Remove(directive)
+	}
+
+	_, hasURI := cloned.directives[ReportURI]
+	_, hasTo := cloned.directives[ReportTo]
+	if !hasURI && !hasTo {
+		cloned.noteUnsafe("", "", "report-only policy has no report-uri or report-to; violations are not reported")
+	}
+	return cloned
+}
@@ -0,0 +1,220 @@
+package csp
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestPolicy_CompileSplit verifies that directives marked with AddReportOnly
+// appear only in the report-only output.
+func TestPolicy_CompileSplit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		setup          func(*Policy)
+		nonce          []string
+		wantEnforce    string
+		wantReportOnly string
+	}{
+		{
+			name:        "no report-only directives",
+			setup:       func(p *Policy) { p.Add(DefaultSrc, SourceSelf) },
+			wantEnforce: "default-src 'self'",
+		},
+		{
+			name: "report-only directive is separated",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.AddReportOnly(ScriptSrc, SourceSelf)
+			},
+			wantEnforce:    "default-src 'self'",
+			wantReportOnly: "script-src 'self'",
+		},
+		{
+			name: "mark covers sources added later",
+			setup: func(p *Policy) {
+				p.AddReportOnly(ScriptSrc, SourceSelf)
+				p.Add(ScriptSrc, "https://cdn.example.com")
+			},
+			wantReportOnly: "script-src 'self' https://cdn.example.com",
+		},
+		{
+			name: "reporting directives are shared",
+			setup: func(p *Policy) {
+				p.Add(DefaultSrc, SourceSelf)
+				p.Add(ReportURI, "https://reports.example.com")
+				p.AddReportOnly(StyleSrc, SourceSelf)
+			},
+			wantEnforce:    "default-src 'self'; report-uri https://reports.example.com",
+			wantReportOnly: "report-uri https://reports.example.com; style-src 'self'",
+		},
+		{
+			name: "nonce is injected into both",
+			setup: func(p *Policy) {
+				p.Add(ScriptSrc, SourceNonce)
+				p.AddReportOnly(StyleSrc, SourceNonce)
+			},
+			nonce:          []string{"abc"},
+			wantEnforce:    "script-src 'nonce-abc'",
+			wantReportOnly: "style-src 'nonce-abc'",
+		},
+		{
+			name: "remove clears the mark",
+			setup: func(p *Policy) {
+				p.AddReportOnly(ScriptSrc, SourceSelf)
+				p.Remove(ScriptSrc)
+				p.Add(ScriptSrc, SourceNone)
+			},
+			wantEnforce: "script-src 'none'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.setup(p)
+			enforce, reportOnly := p.CompileSplit(tt.nonce...)
+			if enforce != tt.wantEnforce {
+				t.Errorf("enforce:\nexpected: %s\ngot:      %s", tt.wantEnforce, enforce)
+			}
+			if reportOnly != tt.wantReportOnly {
+				t.Errorf("report-only:\nexpected: %s\ngot:      %s", tt.wantReportOnly, reportOnly)
+			}
+		})
+	}
+}
+
+// TestCompareEnforceReportOnly verifies that report-only directives looser
+// than or missing from the enforced policy are reported.
+func TestCompareEnforceReportOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		enforce    func(*Policy)
+		reportOnly func(*Policy)
+		expected   []string
+	}{
+		{
+			name: "looser report-only",
+			enforce: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf)
+			},
+			reportOnly: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf, SourceUnsafeInline)
+			},
+			expected: []string{"script-src 'unsafe-inline'"},
+		},
+		{
+			name: "missing directive",
+			enforce: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf)
+				p.Add(ObjectSrc, SourceNone)
+				p.Add(ReportURI, "/a")
+			},
+			reportOnly: func(p *Policy) {
+				p.Add(ScriptSrc, SourceNonce)
+				p.Add(ReportURI, "/b")
+			},
+			expected: []string{"object-src ", "script-src " + SourceNonce},
+		},
+		{
+			name: "stricter report-only",
+			enforce: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf, SourceNonce, SourceUnsafeInline)
+			},
+			reportOnly: func(p *Policy) {
+				p.Add(ScriptSrc, SourceSelf, "'nonce-abc'")
+				p.Add(ImgSrc, SourceSelf)
+			},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			enforce, reportOnly := New(), New()
+			tt.enforce(enforce)
+			tt.reportOnly(reportOnly)
+
+			var got []string
+			for _, r := range CompareEnforceReportOnly(enforce, reportOnly) {
+				if r.Check != CheckReportOnlyMismatch || r.Severity != SeverityWarning {
+					t.Errorf("unexpected check or severity in %+v", r)
+				}
+				got = append(got, r.Directive+" "+r.Source)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("\nexpected: %q\ngot:      %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestCompileBoth verifies that both headers are compiled with the same nonce.
+func TestCompileBoth(t *testing.T) {
+	t.Parallel()
+
+	enforce := New()
+	enforce.Add(ScriptSrc, SourceSelf, SourceNonce)
+	reportOnly := New()
+	reportOnly.Add(ScriptSrc, SourceNonce, SourceStrictDynamic)
+
+	name1, val1, name2, val2 := CompileBoth(enforce, reportOnly, "abc")
+	if name1 != HeaderName || name2 != HeaderNameReportOnly {
+		t.Errorf("header names = %q, %q", name1, name2)
+	}
+	if expected := "script-src 'self' 'nonce-abc'"; val1 != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, val1)
+	}
+	if expected := "script-src 'strict-dynamic' 'nonce-abc'"; val2 != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, val2)
+	}
+
+	if _, val1, _, val2 := CompileBoth(nil, reportOnly, "abc"); val1 != "" || val2 == "" {
+		t.Errorf("CompileBoth(nil, ...) values = %q, %q", val1, val2)
+	}
+}
+
+// TestPolicy_ForEnforcementAndReportOnly verifies the directives kept for each
+// header and that neither clone changes the original.
+func TestPolicy_ForEnforcementAndReportOnly(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(Sandbox, "allow-scripts")
+	p.Add(UpgradeInsecureRequests)
+	p.Add(ReportTo, "csp")
+	p.AddReportOnly(ScriptSrc, SourceSelf)
+	original := p.Compile()
+
+	reportOnly := p.ForReportOnly()
+	expected := "default-src 'self'; report-to csp; script-src 'self'"
+	if got := reportOnly.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+	if hasLintMessage(reportOnly.Lint(), "", "no report-uri or report-to") {
+		t.Error("ForReportOnly() reported missing reporting directives")
+	}
+
+	enforce := p.ForEnforcement()
+	if got := enforce.Compile(); got != original {
+		t.Errorf("\nexpected: %s\ngot:      %s", original, got)
+	}
+	if _, reportValue := enforce.CompileSplit(); reportValue != "" {
+		t.Errorf("ForEnforcement() kept report-only directives: %s", reportValue)
+	}
+
+	if got := p.Compile(); got != original {
+		t.Errorf("original policy changed:\nexpected: %s\ngot:      %s", original, got)
+	}
+
+	p.Remove(ReportTo)
+	if !hasLintMessage(p.ForReportOnly().Lint(), "", "no report-uri or report-to") {
+		t.Error("ForReportOnly() did not report missing reporting directives")
+	}
+}
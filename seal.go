@@ -0,0 +1,19 @@
+//go:build !cspdebug
+
+package csp
+
+// sealState records whether a policy is sealed. It is empty in normal builds,
+// so sealing adds nothing to the size of a Policy.
+type sealState struct{}
+
+// Seal marks the policy as read-only, typically once middleware setup is
+// complete and the policy is shared between requests. In normal builds it is
+// a no-op with no runtime cost. When the package is built with the cspdebug
+// build tag (go build -tags cspdebug), any later change to the sealed policy
+// panics, which catches accidental mutation of a shared policy during
+// development. Clones of a sealed policy are not sealed.
+func (p *Policy) Seal() {}
+
+// checkSealed panics if the policy is sealed in cspdebug builds; otherwise
+// it does nothing. Every method that changes the policy calls it first.
+func (p *Policy) checkSealed() {}
@@ -0,0 +1,24 @@
+//go:build cspdebug
+
+package csp
+
+import "sync/atomic"
+
+// sealState records whether a policy is sealed. The flag is atomic because
+// checkSealed runs before a mutating method takes the policy's lock.
+type sealState struct {
+	sealed atomic.Bool
+}
+
+// Seal marks the policy as read-only; see the non-debug build for details.
+// In this cspdebug build, any later change to the policy panics.
+func (p *Policy) Seal() {
+	p.seal.sealed.Store(true)
+}
+
+// checkSealed panics if the policy has been sealed.
+func (p *Policy) checkSealed() {
+	if p.seal.sealed.Load() {
+		panic("csp: mutation of a sealed policy") //nolint:forbidigo // cspdebug builds fail loudly by design
+	}
+}
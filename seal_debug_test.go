@@ -0,0 +1,44 @@
+//go:build cspdebug
+
+package csp
+
+import "testing"
+
+// TestPolicy_Seal verifies that changing a sealed policy panics in cspdebug
+// builds, while reading it and mutating a clone do not.
+func TestPolicy_Seal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		mutate func(*Policy)
+	}{
+		{"add", func(p *Policy) { p.Add(ImgSrc, SourceSelf) }},
+		{"set", func(p *Policy) { p.Set(ScriptSrc, SourceNone) }},
+		{"remove", func(p *Policy) { p.Remove(ScriptSrc) }},
+		{"source limit", func(p *Policy) { p.SetMaxSourcesPerDirective(10) }},
+		{"disable lint check", func(p *Policy) { p.DisableLintChecks(CheckMissingDefaultSrc) }},
+		{"blocklist without match", func(p *Policy) { p.Blocklist(SourceUnsafeEval) }},
+		{"rejected source", func(p *Policy) { p.AddScheme(ImgSrc, "https") }},
+		{"restore", func(p *Policy) { p.Restore(0) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ScriptSrc, SourceSelf)
+			p.Seal()
+
+			_ = p.Compile()
+			tt.mutate(p.Clone())
+
+			defer func() {
+				if recover() == nil {
+					t.Error("mutation of a sealed policy did not panic")
+				}
+			}()
+			tt.mutate(p)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !cspdebug
+
+package csp
+
+import "testing"
+
+// TestPolicy_Seal verifies that Seal does not restrict the policy in normal
+// builds.
+func TestPolicy_Seal(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Seal()
+	p.Add(ScriptSrc, SourceSelf)
+
+	if got, expected := p.Compile(), "script-src 'self'"; got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
@@ -0,0 +1,288 @@
+package csp
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SourceKind identifies the syntactic category of a CSP source expression.
+type SourceKind int
+
+// These are the source kinds recognized by ClassifySource.
+const (
+	KindUnknown SourceKind = iota // Empty or unrecognized source.
+	KindKeyword                   // Quoted keyword, e.g. 'self' or 'strict-dynamic'.
+	KindScheme                    // Scheme source, e.g. https: or data:.
+	KindHash                      // Hash source, e.g. 'sha256-...'.
+	KindNonce                     // Nonce source or a nonce placeholder.
+	KindHost                      // Host source, e.g. https://example.com or *.example.com.
+)
+
+// String returns a lowercase name for the source kind.
+func (k SourceKind) String() string {
+	switch k {
+	case KindKeyword:
+		return "keyword"
+	case KindScheme:
+		return "scheme"
+	case KindHash:
+		return "hash"
+	case KindNonce:
+		return "nonce"
+	case KindHost:
+		return "host"
+	default:
+		return "unknown"
+	}
+}
+
+// sourceClassifiers holds the classifiers added by RegisterSourceClassifier,
+// in registration order. It is guarded by classifiersMu.
+var sourceClassifiers []func(source string) (SourceKind, bool)
+
+// classifiersMu guards sourceClassifiers.
+var classifiersMu sync.RWMutex
+
+// RegisterSourceClassifier adds a classifier that ClassifySource consults
+// before its built-in rules, for source forms this package does not yet
+// recognize. The classifier receives the trimmed, non-empty source and
+// returns its kind and true, or false to defer to later classifiers and the
+// built-in rules. Classifiers are consulted in registration order. The
+// registration is global: it affects every caller of ClassifySource, and so
+// Lint and the other features built on it, and cannot be undone. It is safe to
+// call concurrently with other functions of the package, but is typically
+// called once during initialization. A nil classifier is ignored.
+func RegisterSourceClassifier(fn func(source string) (SourceKind, bool)) {
+	if fn == nil {
+		return
+	}
+
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+
+	sourceClassifiers = append(sourceClassifiers, fn)
+}
+
+// classifyRegistered returns the kind assigned to s by the first registered
+// classifier that recognizes it.
+func classifyRegistered(s string) (SourceKind, bool) {
+	classifiersMu.RLock()
+	classifiers := sourceClassifiers
+	classifiersMu.RUnlock()
+
+	for _, fn := range classifiers {
+		if kind, ok := fn(s); ok {
+			return kind, true
+		}
+	}
+	return KindUnknown, false
+}
+
+// ClassifySource reports the kind of a single source expression.
+// Leading and trailing whitespace is ignored. Classifiers added with
+// RegisterSourceClassifier take precedence over the built-in rules.
+func ClassifySource(source string) SourceKind {
+	s := strings.TrimSpace(source)
+	if s == "" {
+		return KindUnknown
+	}
+	if kind, ok := classifyRegistered(s); ok {
+		return kind
+	}
+	switch {
+	case isNoncePlaceholder(s):
+		return KindNonce
+	case strings.HasPrefix(s, "'"):
+		return classifyQuoted(s)
+	case isSchemeSource(s):
+		return KindScheme
+	case isHostSource(s):
+		return KindHost
+	default:
+		return KindUnknown
+	}
+}
+
+// SourcesByKind returns the sources of a directive that are of the given kind,
+// sorted alphabetically. It returns nil if the directive is not set or has no
+// matching sources.
+func (p *Policy) SourcesByKind(directive string, kind SourceKind) []string {
+	key := normalizeDirective(directive)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var result []string
+	for s := range p.directives[key] {
+		if ClassifySource(s) == kind {
+			result = append(result, s)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// DynamicSources returns, per directive, the nonce and hash sources of the
+// policy, sorted alphabetically, for auditing which inline content is allowed
+// and which directives expect a nonce at compile time. Nonces include the
+// SourceNonce and named placeholders as well as literal nonce sources.
+// Directives without such sources are omitted; an empty map is returned if
+// there are none.
+func (p *Policy) DynamicSources() map[string][]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make(map[string][]string)
+	for directive, sources := range p.directives {
+		var dynamic []string
+		for s := range sources {
+			if kind := ClassifySource(s); kind == KindNonce || kind == KindHash {
+				dynamic = append(dynamic, s)
+			}
+		}
+		if len(dynamic) > 0 {
+			slices.Sort(dynamic)
+			result[directive] = dynamic
+		}
+	}
+	return result
+}
+
+// classifyQuoted classifies a source that starts with a single quote.
+func classifyQuoted(s string) SourceKind {
+	if len(s) < 3 || !strings.HasSuffix(s, "'") {
+		return KindUnknown
+	}
+	inner := s[1 : len(s)-1]
+	switch {
+	case strings.HasPrefix(inner, "nonce-"):
+		return KindNonce
+	case strings.HasPrefix(inner, "sha256-"),
+		strings.HasPrefix(inner, "sha384-"),
+		strings.HasPrefix(inner, "sha512-"):
+		return KindHash
+	default:
+		return KindKeyword
+	}
+}
+
+// isSchemeSource reports whether s is a bare scheme source such as "https:".
+func isSchemeSource(s string) bool {
+	name, ok := strings.CutSuffix(s, ":")
+	if !ok || name == "" || !isASCIILetter(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		c := name[i]
+		if !isASCIILetter(c) && (c < '0' || c > '9') && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// isHostSource reports whether s looks like a host source expression.
+// It accepts an optional scheme, a host with an optional leading wildcard,
+// an optional port and an optional path.
+func isHostSource(s string) bool {
+	if strings.ContainsAny(s, " \t\r\n;,'") {
+		return false
+	}
+	if _, rest, ok := strings.Cut(s, "://"); ok {
+		s = rest
+	}
+	host := s
+	if i := strings.IndexAny(host, ":/"); i >= 0 {
+		host = host[:i]
+	}
+	return host != ""
+}
+
+// isASCIILetter reports whether c is an ASCII letter.
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// allowedSchemes lists the scheme sources accepted by AddScheme, in lowercase.
+var allowedSchemes = map[string]struct{}{
+	SchemeHTTPS: {},
+	SchemeHTTP:  {},
+	"wss:":      {},
+	"ws:":       {},
+	SchemeData:  {},
+	SchemeBlob:  {},
+	SchemeMedia: {},
+	SchemeFile:  {},
+}
+
+// AddScheme adds scheme sources such as "https:" or "data:" to a directive.
+// Each argument must be one of https:, http:, wss:, ws:, data:, blob:,
+// mediastream: or filesystem:, in any case; anything else, including a
+// scheme missing its colon or a custom scheme, is dropped and reported by
+// Lint. Use Add for other schemes.
+func (p *Policy) AddScheme(directive string, schemes ...string) {
+	p.addChecked(directive, schemes, func(s string) string {
+		if isSchemeSource(s) {
+			if _, ok := allowedSchemes[strings.ToLower(s)]; !ok {
+				return "unknown scheme; dropped"
+			}
+			return ""
+		}
+		if _, ok := allowedSchemes[strings.ToLower(s)+":"]; ok {
+			return "scheme must end with ':'; dropped"
+		}
+		return "not a scheme source; dropped"
+	})
+}
+
+// AddHost adds host sources such as "https://cdn.example.com" or
+// "*.example.com" to a directive. Each argument must be a valid host source
+// as checked by Strict; anything else is dropped and reported by Lint.
+func (p *Policy) AddHost(directive string, hosts ...string) {
+	p.addChecked(directive, hosts, func(s string) string {
+		if ClassifySource(s) != KindHost {
+			return "not a host source; dropped"
+		}
+		if err := validateHostSource(s); err != nil {
+			return err.Error() + "; dropped"
+		}
+		return ""
+	})
+}
+
+// addChecked adds the sources accepted by check to a directive. check returns
+// an empty string for acceptable sources and a lint message otherwise.
+func (p *Policy) addChecked(directive string, sources []string, check func(string) string) {
+	p.checkSealed()
+
+	key := normalizeDirective(directive)
+	if key == "" {
+		return
+	}
+
+	valid := make([]string, 0, len(sources))
+	var rejected []LintResult
+	for _, source := range sources {
+		s := strings.TrimSpace(source)
+		if s == "" {
+			continue
+		}
+		if msg := check(s); msg != "" {
+			rejected = append(rejected, LintResult{Directive: key, Source: s, Message: msg})
+			continue
+		}
+		valid = append(valid, s)
+	}
+
+	if len(rejected) > 0 {
+		p.mu.Lock()
+		for _, r := range rejected {
+			p.noteOnceUnsafe(r.Directive, r.Source, r.Message)
+		}
+		p.mu.Unlock()
+	}
+	if len(valid) > 0 {
+		p.Add(key, valid...)
+	}
+}
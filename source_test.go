@@ -0,0 +1,239 @@
+package csp
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestClassifySource verifies that ClassifySource recognizes every source kind.
+func TestClassifySource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source string
+		want   SourceKind
+	}{
+		{SourceSelf, KindKeyword},
+		{SourceStrictDynamic, KindKeyword},
+		{SourceNone, KindKeyword},
+		{"'nonce-abc'", KindNonce},
+		{SourceNonce, KindNonce},
+		{"'sha256-eHl6'", KindHash},
+		{"'sha384-eHl6'", KindHash},
+		{"'sha512-eHl6'", KindHash},
+		{SchemeHTTPS, KindScheme},
+		{SchemeData, KindScheme},
+		{"web+app:", KindScheme},
+		{"https://example.com", KindHost},
+		{"https://example.com:8443/path", KindHost},
+		{"*.example.com", KindHost},
+		{"*", KindHost},
+		{"example.com", KindHost},
+		{"", KindUnknown},
+		{"   ", KindUnknown},
+		{"'self", KindUnknown},
+		{"https://", KindUnknown},
+		{"bad host", KindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			t.Parallel()
+			if got := ClassifySource(tt.source); got != tt.want {
+				t.Errorf("ClassifySource(%q) = %v, want %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRegisterSourceClassifier verifies that a registered classifier takes
+// precedence over the built-in rules for the forms it recognizes, and that
+// registration is safe during concurrent classification.
+func TestRegisterSourceClassifier(t *testing.T) {
+	t.Parallel()
+
+	// The registration is global, so the form must not be used by other tests.
+	const source = "'x-test-digest-eHl6'"
+
+	if got := ClassifySource(source); got != KindKeyword {
+		t.Fatalf("ClassifySource(%q) before registration = %v, want %v", source, got, KindKeyword)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			ClassifySource(SourceSelf)
+		}
+	}()
+	RegisterSourceClassifier(nil)
+	RegisterSourceClassifier(func(s string) (SourceKind, bool) {
+		if strings.HasPrefix(s, "'x-test-digest-") {
+			return KindHash, true
+		}
+		return KindUnknown, false
+	})
+	wg.Wait()
+
+	tests := []struct {
+		source string
+		want   SourceKind
+	}{
+		{source, KindHash},
+		{"  " + source + "  ", KindHash},
+		{SourceSelf, KindKeyword},
+		{"https://example.com", KindHost},
+		{"", KindUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifySource(tt.source); got != tt.want {
+			t.Errorf("ClassifySource(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+// TestPolicy_SourcesByKind verifies that SourcesByKind filters and sorts the
+// sources of a directive by kind.
+func TestPolicy_SourcesByKind(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, SourceNonce, "'sha256-eHl6'", SchemeHTTPS, "https://b.com", "https://a.com")
+
+	tests := []struct {
+		name      string
+		directive string
+		kind      SourceKind
+		want      []string
+	}{
+		{"keyword", ScriptSrc, KindKeyword, []string{SourceSelf}},
+		{"nonce", ScriptSrc, KindNonce, []string{SourceNonce}},
+		{"hash", ScriptSrc, KindHash, []string{"'sha256-eHl6'"}},
+		{"scheme", ScriptSrc, KindScheme, []string{SchemeHTTPS}},
+		{"host sorted", ScriptSrc, KindHost, []string{"https://a.com", "https://b.com"}},
+		{"no match", ScriptSrc, KindUnknown, nil},
+		{"missing directive", StyleSrc, KindKeyword, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.SourcesByKind(tt.directive, tt.kind); !slices.Equal(got, tt.want) {
+				t.Errorf("SourcesByKind(%q, %v) = %v, want %v", tt.directive, tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicy_DynamicSources verifies that nonce and hash sources are listed
+// per directive and other directives are omitted.
+func TestPolicy_DynamicSources(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, SourceNonce, "'sha256-eHl6'", SourceStrictDynamic)
+	p.Add(StyleSrc, "'nonce-abc'", NamedNonce("styles"))
+	p.Add(ImgSrc, SourceSelf)
+
+	expected := map[string][]string{
+		ScriptSrc: {"'sha256-eHl6'", SourceNonce},
+		StyleSrc:  {"'nonce-abc'", NamedNonce("styles")},
+	}
+	got := p.DynamicSources()
+	if !maps.EqualFunc(got, expected, slices.Equal[[]string]) {
+		t.Errorf("\nexpected: %v\ngot:      %v", expected, got)
+	}
+
+	if got := New().DynamicSources(); got == nil || len(got) != 0 {
+		t.Errorf("DynamicSources() of empty policy = %v, want empty map", got)
+	}
+}
+
+// TestPolicy_AddSchemeAndHost verifies that AddScheme and AddHost add only
+// sources of their kind and report the others through Lint.
+func TestPolicy_AddSchemeAndHost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		add      func(*Policy)
+		expected string
+		wantLint string
+	}{
+		{
+			name:     "valid schemes",
+			add:      func(p *Policy) { p.AddScheme(ImgSrc, SchemeHTTPS, SchemeData, "WSS:") },
+			expected: "img-src WSS: data: https:",
+		},
+		{
+			name:     "unknown scheme",
+			add:      func(p *Policy) { p.AddScheme(ImgSrc, "foo:", "web+app:", SchemeBlob) },
+			expected: "img-src blob:",
+			wantLint: "unknown scheme",
+		},
+		{
+			name:     "scheme missing colon",
+			add:      func(p *Policy) { p.AddScheme(ImgSrc, "https", SchemeData) },
+			expected: "img-src data:",
+			wantLint: "must end with ':'",
+		},
+		{
+			name:     "host passed as scheme",
+			add:      func(p *Policy) { p.AddScheme(ImgSrc, "https://a.com") },
+			expected: "",
+			wantLint: "not a scheme source",
+		},
+		{
+			name:     "valid hosts",
+			add:      func(p *Policy) { p.AddHost(ImgSrc, "https://a.com", "*.b.com", "c.com:8080/path/") },
+			expected: "img-src *.b.com c.com:8080/path/ https://a.com",
+		},
+		{
+			name:     "keyword passed as host",
+			add:      func(p *Policy) { p.AddHost(ImgSrc, SourceSelf, "https://a.com") },
+			expected: "img-src https://a.com",
+			wantLint: "not a host source",
+		},
+		{
+			name:     "malformed host",
+			add:      func(p *Policy) { p.AddHost(ImgSrc, "https://bad_host.com") },
+			expected: "",
+			wantLint: "invalid host",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			tt.add(p)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			if tt.wantLint != "" && !hasLintMessage(p.Lint(), ImgSrc, tt.wantLint) {
+				t.Errorf("Lint() = %v, want a finding containing %q", p.Lint(), tt.wantLint)
+			}
+		})
+	}
+}
+
+// TestPolicy_AddScheme_RepeatedRejection verifies that the same rejected
+// source is reported once however often it is passed.
+func TestPolicy_AddScheme_RepeatedRejection(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.DisableLintChecks(CheckMissingDefaultSrc)
+	for range 100 {
+		p.AddScheme(ImgSrc, "https")
+		p.AddHost(ImgSrc, SourceSelf)
+	}
+	if got := len(p.Lint()); got != 2 {
+		t.Errorf("Lint() = %v, want 2 findings", p.Lint())
+	}
+}
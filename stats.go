@@ -0,0 +1,40 @@
+package csp
+
+import "sync/atomic"
+
+// Stats reports how often a policy's compiled header was rebuilt or served
+// from the cache, for detecting code that needlessly invalidates the cache in
+// a hot path.
+type Stats struct {
+	Rebuilds           uint64 // Number of times the cached header was built.
+	CacheHits          uint64 // Number of compilations served from a valid cache.
+	NonceSubstitutions uint64 // Number of times a nonce was substituted into the cached header.
+}
+
+// policyStats holds the counters behind Stats. They are atomic because the
+// cache fast path of Compile runs under the read lock only, where plain
+// increments by concurrent callers would race.
+type policyStats struct {
+	rebuilds           atomic.Uint64
+	cacheHits          atomic.Uint64
+	nonceSubstitutions atomic.Uint64
+}
+
+// Stats returns the compilation counters of the policy. Cache hits are
+// counted by the methods that return the cached header: Compile,
+// CompileBytes, CompileInto, CompileFunc, CompileSafe, CompileWithFreshNonce,
+// CompileNamed and CompileTemplate. Nonce substitutions are counted by the
+// first six; CompileNamed and CompileTemplate substitute without the memo.
+// CompilableWithoutNonce and MaxCompiledSize only inspect the cache and count
+// no hits, although they count a rebuild if they find the cache invalid. The
+// uncached variants such as CompileWith are not counted. A compilation that
+// finds the cache invalid counts as a rebuild, not a hit.
+// Repeated compilation with the same nonce is served from a memo and counts
+// as a single substitution. Clones start with zero counters.
+func (p *Policy) Stats() Stats {
+	return Stats{
+		Rebuilds:           p.stats.rebuilds.Load(),
+		CacheHits:          p.stats.cacheHits.Load(),
+		NonceSubstitutions: p.stats.nonceSubstitutions.Load(),
+	}
+}
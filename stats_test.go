@@ -0,0 +1,41 @@
+package csp
+
+import "testing"
+
+// TestPolicy_Stats verifies that the counters track cache rebuilds, cache
+// hits and nonce substitutions.
+func TestPolicy_Stats(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, SourceNonce)
+
+	p.Compile("a") // Rebuild and substitution
+	p.Compile("a") // Hit, served from the nonce memo
+	p.Compile("b") // Hit and substitution
+	want := Stats{Rebuilds: 1, CacheHits: 2, NonceSubstitutions: 2}
+	if got := p.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	p.Add(ImgSrc, SchemeData)
+	p.Add(ImgSrc, SchemeBlob)
+	p.Compile("b")
+	p.CompileBytes("b") // Served from the nonce memo
+	want = Stats{Rebuilds: 2, CacheHits: 3, NonceSubstitutions: 3}
+	if got := p.Stats(); got != want {
+		t.Errorf("Stats() after mutation = %+v, want %+v", got, want)
+	}
+
+	p.CompilableWithoutNonce()
+	p.MaxCompiledSize(0)
+	p.CompileNamed(map[string]string{"": "c"}) // Hit
+	want = Stats{Rebuilds: 2, CacheHits: 4, NonceSubstitutions: 3}
+	if got := p.Stats(); got != want {
+		t.Errorf("Stats() after inspection = %+v, want %+v", got, want)
+	}
+
+	if got := p.Clone().Stats(); got != (Stats{}) {
+		t.Errorf("Clone().Stats() = %+v, want zero", got)
+	}
+}
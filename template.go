@@ -0,0 +1,127 @@
+package csp
+
+import "strings"
+
+// CompileTemplate generates the CSP header string like Compile, replacing
+// every {{name}} token in the sources with vars[name], so that one policy can
+// carry environment-specific values such as a CDN host added as "{{cdn}}".
+// Tokens without an entry in vars are left as they are. Values are inserted
+// verbatim and must come from trusted configuration; a value may hold several
+// space-separated sources.
+//
+// Nonce placeholders are tokens too: SourceNonce is named "nonce" and
+// NamedNonce(name) is named "nonce:" followed by the name. They are only
+// replaced where they form a whole source. Their values are written as nonce
+// sources, and without a value they are kept wrapped as a nonce source, as
+// Compile does.
+func (p *Policy) CompileTemplate(vars map[string]string) string {
+	cache, _, _ := p.compiledCache()
+	if !strings.Contains(cache, "{{") {
+		return cache
+	}
+	return replaceTokens(cache, func(token, name string, whole bool) string {
+		value, ok := vars[name]
+		if isNoncePlaceholder(token) {
+			if !whole {
+				return token
+			}
+			return substituteNonce(token, value)
+		}
+		if !ok {
+			return token
+		}
+		return value
+	})
+}
+
+// replaceTokens replaces every {{name}} token in header with the result of
+// replace, which receives the whole token, the name between the braces, and
+// whether the token is a whole source rather than part of one. Names cannot
+// be empty or contain spaces or braces; such text is copied unchanged.
+func replaceTokens(header string, replace func(token, name string, whole bool) string) string {
+	var b strings.Builder
+	b.Grow(len(header) + 32)
+	pos := 0
+	for {
+		i := strings.Index(header[pos:], "{{")
+		if i < 0 {
+			break
+		}
+		start := pos + i
+		end := strings.Index(header[start+2:], "}}")
+		if end < 0 {
+			break
+		}
+		b.WriteString(header[pos:start])
+		name := header[start+2 : start+2+end]
+		if !isTokenName(name) {
+			b.WriteByte('{')
+			pos = start + 1
+			continue
+		}
+		pos = start + end + 4
+		b.WriteString(replace(header[start:pos], name, isWholeSource(header, start, pos)))
+	}
+	b.WriteString(header[pos:])
+	return b.String()
+}
+
+// replaceSource replaces every occurrence of source in header that is a whole
+// source, bounded by the start or a space on the left and by the end, a space
+// or a semicolon on the right, with replacement. Occurrences inside a longer
+// source are left unchanged.
+func replaceSource(header, source, replacement string) string {
+	var b strings.Builder
+	b.Grow(len(header) + 32)
+	pos := 0
+	for {
+		i := strings.Index(header[pos:], source)
+		if i < 0 {
+			break
+		}
+		start, end := pos+i, pos+i+len(source)
+		b.WriteString(header[pos:start])
+		if isWholeSource(header, start, end) {
+			b.WriteString(replacement)
+		} else {
+			b.WriteString(source)
+		}
+		pos = end
+	}
+	b.WriteString(header[pos:])
+	return b.String()
+}
+
+// isWholeSource reports whether header[start:end] is a complete source of a
+// serialized policy.
+func isWholeSource(header string, start, end int) bool {
+	return (start == 0 || header[start-1] == ' ') &&
+		(end == len(header) || header[end] == ' ' || header[end] == ';')
+}
+
+// isTokenName reports whether name can appear between the braces of a
+// template token.
+func isTokenName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t{}")
+}
+
+// isTemplateSource reports whether a source consists of a single template
+// token, such as SourceNonce or "{{cdn}}".
+func isTemplateSource(source string) bool {
+	name, ok := strings.CutPrefix(source, "{{")
+	if !ok {
+		return false
+	}
+	name, ok = strings.CutSuffix(name, "}}")
+	return ok && isTokenName(name)
+}
+
+// hasTemplateToken reports whether a source contains a template token.
+func hasTemplateToken(source string) bool {
+	found := false
+	replaceTokens(source, func(token, _ string, _ bool) string {
+		found = true
+		return token
+	})
+	return found
+}
@@ -0,0 +1,130 @@
+package csp
+
+import "testing"
+
+// TestPolicy_CompileTemplate verifies interpolation of nonce placeholders and
+// custom tokens.
+func TestPolicy_CompileTemplate(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ScriptSrc, SourceSelf, SourceNonce, "{{cdn}}")
+	p.Add(ImgSrc, "https://{{region}}.img.example.com")
+	p.Add(StyleSrc, NamedNonce("styles"))
+
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		expected string
+	}{
+		{
+			name: "all supplied",
+			vars: map[string]string{
+				"nonce": "abc", "nonce:styles": "def", "cdn": "https://cdn.example.com", "region": "eu",
+			},
+			expected: "img-src https://eu.img.example.com; " +
+				"script-src 'self' https://cdn.example.com 'nonce-abc'; style-src 'nonce-def'",
+		},
+		{
+			name: "unmatched tokens",
+			vars: map[string]string{"cdn": "https://cdn.example.com"},
+			expected: "img-src https://{{region}}.img.example.com; " +
+				"script-src 'self' https://cdn.example.com 'nonce-{{nonce}}'; style-src 'nonce-{{nonce:styles}}'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.CompileTemplate(tt.vars); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+
+	if err := p.Strict(); err != nil {
+		t.Errorf("Strict() rejected template tokens: %v", err)
+	}
+}
+
+// TestPolicy_Strict_TemplateTokens verifies that Strict validates the host
+// syntax around embedded template tokens.
+func TestPolicy_Strict_TemplateTokens(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source  string
+		wantErr bool
+	}{
+		{"{{cdn}}", false},
+		{SourceNonce, false},
+		{"https://{{region}}.img.example.com", false},
+		{"https://cdn.example.com/{{version}}/", false},
+		{"{{scheme}}://cdn.example.com", false},
+		{"https://{{x}}/a b;c", true},
+		{"https://{{x}}_bad.example.com", true},
+		{"https://{{x}}.example.com:port", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(ImgSrc, tt.source)
+			if err := p.Strict(); (err != nil) != tt.wantErr {
+				t.Errorf("Strict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestReplaceTokens verifies that malformed tokens are copied unchanged.
+func TestReplaceTokens(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{"{{a}}", "<a>"},
+		{"x{{a}}y{{b}}z", "x<a>y<b>z"},
+		{"{{}}", "{{}}"},
+		{"{{a", "{{a"},
+		{"{{{a}}", "{<a>"},
+		{"{{a b}}", "{{a b}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			got := replaceTokens(tt.in, func(_, name string, _ bool) string { return "<" + name + ">" })
+			if got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestReplaceSource verifies that only whole sources are replaced.
+func TestReplaceSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{"{{nonce}}", "N"},
+		{"a {{nonce}}; b {{nonce}}", "a N; b N"},
+		{"a '{{nonce}}' {{nonce}}x x{{nonce}}", "a '{{nonce}}' {{nonce}}x x{{nonce}}"},
+		{"a {{nonce}}{{nonce}}", "a {{nonce}}{{nonce}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			if got := replaceSource(tt.in, SourceNonce, "N"); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package csp
+
+import "testing"
+
+// TestPolicy_SetTrustedTypes verifies the quoting rules applied by
+// SetTrustedTypes.
+func TestPolicy_SetTrustedTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		policyNames     []string
+		allowDuplicates bool
+		expected        string
+		wantLint        bool
+	}{
+		{
+			name:            "single name with duplicates allowed",
+			policyNames:     []string{"myPolicy"},
+			allowDuplicates: true,
+			expected:        "trusted-types 'allow-duplicates' myPolicy", // Sources are sorted; quotes sort first.
+		},
+		{
+			name:        "over-quoted names are unquoted",
+			policyNames: []string{"'myPolicy'", "dompurify"},
+			expected:    "trusted-types dompurify myPolicy",
+		},
+		{
+			name:        "quoted keywords are kept",
+			policyNames: []string{"'none'", "myPolicy", "'allow-duplicates'"},
+			expected:    "trusted-types 'allow-duplicates' myPolicy",
+		},
+		{
+			name:        "none keyword alone",
+			policyNames: []string{" 'none' "},
+			expected:    "trusted-types 'none'",
+		},
+		{
+			name:            "no names",
+			policyNames:     nil,
+			allowDuplicates: true,
+			expected:        "trusted-types 'none'",
+		},
+		{
+			name:        "invalid name is dropped",
+			policyNames: []string{"my policy", "valid-name"},
+			expected:    "trusted-types valid-name",
+			wantLint:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.SetTrustedTypes(tt.policyNames, tt.allowDuplicates)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+			if got := hasLintMessage(p.Lint(), TrustedTypes, "dropped"); got != tt.wantLint {
+				t.Errorf("dropped name lint = %v, want %v", got, tt.wantLint)
+			}
+		})
+	}
+}
+
+// TestPolicy_EnableTrustedTypes verifies that EnableTrustedTypes sets both
+// Trusted Types directives with the correct quoting.
+func TestPolicy_EnableTrustedTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		policyNames []string
+		expected    string
+	}{
+		{
+			name:        "policy names",
+			policyNames: []string{"'default'", "dompurify"},
+			expected:    "require-trusted-types-for 'script'; trusted-types default dompurify",
+		},
+		{
+			name:        "no names blocks all policies",
+			policyNames: nil,
+			expected:    "require-trusted-types-for 'script'; trusted-types 'none'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := New()
+			p.Add(TrustedTypes, "old")
+			p.Compile() // Prime the cache to verify invalidation
+			p.EnableTrustedTypes(tt.policyNames...)
+			if got := p.Compile(); got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package csp
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// validateHostSource checks a host source such as "https://*.example.com:443/path"
+// against the CSP host-source grammar. Unlike net/url, it accepts a wildcard
+// scheme, a leading wildcard label in the host and a wildcard port, and it
+// allows the scheme to be omitted.
+func validateHostSource(source string) error {
+	if strings.ContainsFunc(source, unicode.IsSpace) {
+		return fmt.Errorf("host source %q contains whitespace", source)
+	}
+
+	rest := source
+	if scheme, after, ok := strings.Cut(source, "://"); ok {
+		if scheme != "*" && !isSchemeSource(scheme+":") {
+			return fmt.Errorf("invalid scheme in host source %q", source)
+		}
+		rest = after
+	}
+
+	hostPort, path := rest, ""
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		hostPort, path = rest[:i], rest[i:]
+	}
+	host, port, hasPort := strings.Cut(hostPort, ":")
+
+	if err := validateHost(host); err != nil {
+		return fmt.Errorf("host source %q: %w", source, err)
+	}
+	if hasPort && port != "*" && !isDigits(port) {
+		return fmt.Errorf("invalid port in host source %q", source)
+	}
+	if _, err := url.Parse(path); err != nil {
+		return fmt.Errorf("invalid path in host source %q: %w", source, err)
+	}
+	return nil
+}
+
+// validateHost checks the host part of a host source. The host must be "*"
+// or a sequence of dot-separated labels, the first of which may be "*".
+func validateHost(host string) error {
+	if host == "*" {
+		return nil
+	}
+	labels := strings.Split(strings.TrimPrefix(host, "*."), ".")
+	for _, label := range labels {
+		if strings.Contains(label, "*") {
+			return fmt.Errorf("invalid wildcard %q (must be '*' or '*.domain')", host)
+		}
+		if label == "" || !isHostLabel(label) {
+			return fmt.Errorf("invalid host %q", host)
+		}
+	}
+	return nil
+}
+
+// isHostLabel reports whether label consists of ASCII letters, digits and dashes.
+func isHostLabel(label string) bool {
+	for i := range len(label) {
+		c := label[i]
+		if !isASCIILetter(c) && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,41 @@
+package csp
+
+import (
+	"fmt"
+	"slices"
+)
+
+// MarshalYAML implements the Marshaler interface of gopkg.in/yaml.v3 and
+// gopkg.in/yaml.v2, encoding the policy as a mapping from directive name to a
+// sorted sequence of sources. Valueless directives map to empty sequences,
+// and the nonce placeholder is kept as is. The YAML package itself is not a
+// dependency; any encoder that honors the interface can be used.
+func (p *Policy) MarshalYAML() (any, error) {
+	directives := p.normalizedDirectives(identitySource)
+
+	result := make(map[string][]string, len(directives))
+	for directive, sources := range directives {
+		list := make([]string, 0, len(sources))
+		for s := range sources {
+			list = append(list, s)
+		}
+		slices.Sort(list)
+		result[directive] = list
+	}
+	return result, nil
+}
+
+// UnmarshalYAML implements the function-based Unmarshaler interface of
+// gopkg.in/yaml.v2, which gopkg.in/yaml.v3 also supports. It decodes a
+// mapping from directive name to a sequence of sources, as produced by
+// MarshalYAML, and replaces the policy with it as SetAll does, including the
+// normalization of names and sources. A directive with a null or empty
+// sequence is kept only if it is valueless.
+func (p *Policy) UnmarshalYAML(unmarshal func(any) error) error {
+	var directives map[string][]string
+	if err := unmarshal(&directives); err != nil {
+		return fmt.Errorf("decode policy: %w", err)
+	}
+	p.SetAll(directives)
+	return nil
+}
@@ -0,0 +1,80 @@
+package csp
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// yamlRoundTrip encodes p with MarshalYAML and decodes the result into a new
+// policy with UnmarshalYAML. JSON stands in for the YAML codec, since every
+// JSON document is also valid YAML and the marshalers are format-agnostic;
+// the package depends on the standard library only, so no YAML decoder is
+// available to the tests.
+func yamlRoundTrip(t *testing.T, p *Policy) *Policy {
+	t.Helper()
+
+	value, err := p.MarshalYAML()
+	if err != nil {
+		t.Fatalf("MarshalYAML() error = %v", err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	decoded := New()
+	err = decoded.UnmarshalYAML(func(v any) error {
+		return json.Unmarshal(data, v)
+	})
+	if err != nil {
+		t.Fatalf("UnmarshalYAML() error = %v", err)
+	}
+	return decoded
+}
+
+// TestPolicy_YAML verifies that a policy survives a round trip through the
+// YAML marshalers.
+func TestPolicy_YAML(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(DefaultSrc, SourceSelf)
+	p.Add(ScriptSrc, SourceSelf, SourceNonce, "https://cdn.example.com")
+	p.Add(UpgradeInsecureRequests)
+
+	decoded := yamlRoundTrip(t, p)
+	if !decoded.Equal(p) {
+		t.Errorf("\nexpected: %s\ngot:      %s", p.Compile(), decoded.Compile())
+	}
+	if got, want := decoded.Compile("abc"), p.Compile("abc"); got != want {
+		t.Errorf("\nexpected: %s\ngot:      %s", want, got)
+	}
+}
+
+// TestPolicy_UnmarshalYAML verifies normalization and error handling when
+// decoding a policy.
+func TestPolicy_UnmarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(ImgSrc, SchemeData)
+
+	doc := []byte(`{"Script-Src": [" 'self' ", ""], "style-src": [], "sandbox": null}`)
+	if err := p.UnmarshalYAML(func(v any) error { return json.Unmarshal(doc, v) }); err != nil {
+		t.Fatalf("UnmarshalYAML() error = %v", err)
+	}
+	expected := "sandbox; script-src 'self'"
+	if got := p.Compile(); got != expected {
+		t.Errorf("\nexpected: %s\ngot:      %s", expected, got)
+	}
+
+	errDecode := errors.New("bad document")
+	err := p.UnmarshalYAML(func(any) error { return errDecode })
+	if !errors.Is(err, errDecode) {
+		t.Errorf("UnmarshalYAML() error = %v, want %v", err, errDecode)
+	}
+	if got := p.Compile(); got != expected {
+		t.Errorf("policy changed on error:\nexpected: %s\ngot:      %s", expected, got)
+	}
+}